@@ -0,0 +1,823 @@
+// Package config loads the versioned configuration file that describes how the CDK app is deployed.
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code-refactoring-infra/stack"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/jsii-runtime-go"
+)
+
+const (
+	// Version is the configuration schema version understood by Load.
+	Version = 1
+
+	// ContextKey is the CDK context key used to select a configuration file, e.g. `cdk -c config=config/prod.json`.
+	ContextKey = "config"
+
+	// DefaultPath is the configuration file used when no context value is given.
+	DefaultPath = "config/dev.json"
+
+	// LayoutSingle deploys everything as one stack, which suits small sandboxes.
+	LayoutSingle = "single"
+
+	// LayoutSplit deploys separate network, data, compute and edge stacks.
+	LayoutSplit = "split"
+)
+
+var (
+	stackIDPattern     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]{0,127}$`)
+	accountPattern     = regexp.MustCompile(`^[0-9]{12}$`)
+	regionPattern      = regexp.MustCompile(`^[a-z]{2}(-gov)?-[a-z]+-[0-9]$`)
+	namePrefixPattern  = regexp.MustCompile(`^[a-z][a-z0-9-]{0,22}[a-z0-9]$`)
+	vpcIDPattern       = regexp.MustCompile(`^vpc-[0-9a-f]{8,17}$`)
+	subnetNamePattern  = regexp.MustCompile(`^[A-Za-z0-9-]{1,64}$`)
+	identifierPattern  = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+	readerClassPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*\.[a-z0-9]+$`)
+	domainNamePattern  = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+	hostedZonePattern  = regexp.MustCompile(`^Z[A-Z0-9]{1,31}$`)
+	certificatePattern = regexp.MustCompile(`^arn:aws[a-z-]*:acm:([a-z0-9-]+):[0-9]{12}:certificate/[0-9a-f-]{36}$`)
+	tagValuePattern    = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]{0,256}$`)
+)
+
+var (
+	backupWindowPattern      = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d-([01]\d|2[0-3]):[0-5]\d$`)
+	maintenanceWindowPattern = regexp.MustCompile(`^(mon|tue|wed|thu|fri|sat|sun):([01]\d|2[0-3]):[0-5]\d-(mon|tue|wed|thu|fri|sat|sun):([01]\d|2[0-3]):[0-5]\d$`)
+	backupVaultPattern       = regexp.MustCompile(`^arn:aws[a-z-]*:backup:[a-z0-9-]+:[0-9]{12}:backup-vault:[A-Za-z0-9_.-]{2,50}$`)
+	snapshotPattern          = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9:-]{0,254}|arn:aws[a-z-]*:rds:[a-z0-9-]+:[0-9]{12}:cluster-snapshot:[A-Za-z][A-Za-z0-9:-]{0,254})$`)
+)
+
+// Units for comparing the daily backup window with the weekly maintenance window.
+const (
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
+)
+
+// weekdays numbers the days of the maintenance window from Monday.
+var weekdays = map[string]int{"mon": 0, "tue": 1, "wed": 2, "thu": 3, "fri": 4, "sat": 5, "sun": 6}
+
+// Config is the deployment configuration read from a JSON file.
+type Config struct {
+	// Version is the schema version of the file and must equal Version.
+	Version int `json:"version"`
+	// StackID is the CloudFormation stack name.
+	StackID string `json:"stackId"`
+	// Account is the target AWS account. Leave empty to use the CLI credentials' account.
+	Account string `json:"account,omitempty"`
+	// Region is the target AWS region.
+	Region string `json:"region"`
+	// Layout is either LayoutSingle or LayoutSplit. Defaults to LayoutSingle.
+	Layout string `json:"layout,omitempty"`
+	// NamePrefix prefixes every physical resource name, export name and parameter path.
+	NamePrefix string `json:"namePrefix"`
+	// Environment selects a built-in profile and optionally overrides its values.
+	Environment Environment `json:"environment"`
+	// Network selects the VPC layout. Defaults to public subnets only.
+	Network *Network `json:"network,omitempty"`
+	// LoadBalancer selects how the backend load balancer is exposed. Defaults to internet-facing.
+	LoadBalancer *LoadBalancer `json:"loadBalancer,omitempty"`
+
+	// Domains serves the API, hosted UI and frontend under custom domain names. Defaults to the AWS generated names.
+	Domains *Domains `json:"domains,omitempty"`
+	// Database names the Aurora database and vector table and sets its backups.
+	Database *Database `json:"database,omitempty"`
+	// FoundationModels overrides the Bedrock models the agent may invoke.
+	FoundationModels []string `json:"foundationModels,omitempty"`
+	// Tags overrides the values of the tags applied to every resource.
+	Tags *Tags `json:"tags,omitempty"`
+}
+
+// Environment selects a built-in environment profile. Set fields override the profile values.
+type Environment struct {
+	Profile               string   `json:"profile"`
+	DatabaseMinCapacity   *float64 `json:"databaseMinCapacity,omitempty"`
+	DatabaseMaxCapacity   *float64 `json:"databaseMaxCapacity,omitempty"`
+	TaskCPU               *float64 `json:"taskCpu,omitempty"`
+	TaskMemoryMiB         *float64 `json:"taskMemoryMiB,omitempty"`
+	DesiredCount          *float64 `json:"desiredCount,omitempty"`
+	LogRetentionDays      *int     `json:"logRetentionDays,omitempty"`
+	DataProtection        *bool    `json:"dataProtection,omitempty"`
+	EnforceSecurityPolicy *bool    `json:"enforceSecurityPolicy,omitempty"`
+
+	// DatabaseReaders replaces the profile's Aurora readers; an empty list removes them.
+	DatabaseReaders *[]DatabaseReader `json:"databaseReaders,omitempty"`
+}
+
+// DatabaseReader declares an Aurora reader instance.
+type DatabaseReader struct {
+	// InstanceType makes the reader a provisioned instance of this class, such as r6g.large. Unset selects
+	// Serverless v2.
+	InstanceType string `json:"instanceType,omitempty"`
+	// PromotionTier orders the readers for failover, from 0, promoted first, to 15.
+	PromotionTier int `json:"promotionTier,omitempty"`
+}
+
+// Network selects the VPC layout.
+type Network struct {
+	// Egress is one of "public", "nat-gateway", "nat-instance" or "none". Defaults to "public".
+	Egress string `json:"egress,omitempty"`
+	// VpcEndpoints adds gateway and interface endpoints for the AWS services the workloads call.
+	VpcEndpoints bool `json:"vpcEndpoints,omitempty"`
+	// FlowLogs is "cloudwatch-logs" or "s3" to record the VPC's traffic. Records are kept as long as the
+	// environment's logs.
+	FlowLogs string `json:"flowLogs,omitempty"`
+	// DualStack gives the VPC, its subnets and the load balancer IPv6 addresses alongside IPv4.
+	DualStack bool `json:"dualStack,omitempty"`
+	// ExistingVpc deploys into a centrally managed VPC instead of creating one. Egress is then up to that VPC.
+	ExistingVpc *ExistingVpc `json:"existingVpc,omitempty"`
+}
+
+// ExistingVpc names a VPC that is looked up at synthesis time and the subnet groups the stack uses in it.
+type ExistingVpc struct {
+	VpcID               string `json:"vpcId"`
+	WorkloadSubnetGroup string `json:"workloadSubnetGroup,omitempty"`
+	DatabaseSubnetGroup string `json:"databaseSubnetGroup,omitempty"`
+}
+
+// LoadBalancer selects how the backend load balancer is exposed.
+type LoadBalancer struct {
+	// Internal hides the load balancer from the internet; API Gateway reaches it through a VPC link.
+	Internal bool `json:"internal,omitempty"`
+
+	// HTTPS serves the load balancer over TLS under a custom domain name.
+	HTTPS *HTTPS `json:"https,omitempty"`
+}
+
+// HTTPS names the load balancer's domain and where its certificate comes from: a certificate requested and
+// validated through the hosted zone, or an existing one.
+type HTTPS struct {
+	DomainName     string      `json:"domainName"`
+	HostedZone     *HostedZone `json:"hostedZone,omitempty"`
+	CertificateArn string      `json:"certificateArn,omitempty"`
+}
+
+// Domains names the custom domains of the public entry points in one hosted zone. Unset names keep the AWS
+// generated ones.
+type Domains struct {
+	HostedZone *HostedZone `json:"hostedZone"`
+	API        string      `json:"api,omitempty"`
+	Auth       string      `json:"auth,omitempty"`
+	Frontend   string      `json:"frontend,omitempty"`
+}
+
+// HostedZone identifies a public Route 53 hosted zone.
+type HostedZone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Database names the Aurora database, declares its vector tables and sets how it is backed up and restored.
+type Database struct {
+	Name      string `json:"name,omitempty"`
+	TableName string `json:"tableName,omitempty"`
+	// VectorStores declares several vector tables instead of TableName. The first one is the backend's.
+	VectorStores []VectorStore `json:"vectorStores,omitempty"`
+	Migrations   *Migrations   `json:"migrations,omitempty"`
+
+	// Backup sets the Aurora backup retention and windows and an optional AWS Backup plan.
+	Backup *Backup `json:"backup,omitempty"`
+	// RestoreSnapshotIdentifier creates the cluster from this cluster snapshot, by name or ARN, to rehearse a
+	// restore.
+	RestoreSnapshotIdentifier string `json:"restoreSnapshotIdentifier,omitempty"`
+}
+
+// Backup sets how the Aurora cluster is backed up. Unset fields keep the defaults of stack.DatabaseBackupConfig.
+type Backup struct {
+	// RetentionDays is how many days of automated backups are kept, from 1 to 35. Defaults to the profile's.
+	RetentionDays int `json:"retentionDays,omitempty"`
+	// PreferredBackupWindow is the daily UTC window backups start in, such as "03:00-04:00".
+	PreferredBackupWindow string `json:"preferredBackupWindow,omitempty"`
+	// PreferredMaintenanceWindow is the weekly UTC maintenance window, such as "sun:04:30-sun:05:30".
+	PreferredMaintenanceWindow string `json:"preferredMaintenanceWindow,omitempty"`
+	// Plan also backs the cluster up daily with AWS Backup.
+	Plan *BackupPlan `json:"plan,omitempty"`
+}
+
+// BackupPlan keeps daily AWS Backup recovery points of the cluster in a vault, optionally locked and copied to
+// another vault.
+type BackupPlan struct {
+	RetentionDays     int        `json:"retentionDays"`
+	ContinuousBackup  bool       `json:"continuousBackup,omitempty"`
+	VaultLock         *VaultLock `json:"vaultLock,omitempty"`
+	CopyToVaultArn    string     `json:"copyToVaultArn,omitempty"`
+	CopyRetentionDays int        `json:"copyRetentionDays,omitempty"`
+}
+
+// VaultLock bounds the retention of the vault's recovery points. Without changeableForDays the lock stays in
+// governance mode; with it the lock becomes immutable after that many days.
+type VaultLock struct {
+	MinRetentionDays  int `json:"minRetentionDays"`
+	MaxRetentionDays  int `json:"maxRetentionDays,omitempty"`
+	ChangeableForDays int `json:"changeableForDays,omitempty"`
+}
+
+// VectorStore declares a vector table embedded with its own model. Unset fields keep the defaults of
+// stack.VectorStoreConfig.
+type VectorStore struct {
+	TableName      string `json:"tableName"`
+	EmbeddingModel string `json:"embeddingModel,omitempty"`
+	IndexType      string `json:"indexType,omitempty"`
+	DistanceMetric string `json:"distanceMetric,omitempty"`
+}
+
+// Migrations selects how far deployments migrate the vector tables.
+type Migrations struct {
+	// TargetVersion is the migration version to migrate up or down to. Zero or unset migrates to the latest.
+	TargetVersion int `json:"targetVersion,omitempty"`
+	// DryRun logs the migrations that would run without applying them.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// Tags overrides tag values. Unset fields keep the defaults of stack.TagSchema.
+type Tags struct {
+	Project            string `json:"project,omitempty"`
+	Environment        string `json:"environment,omitempty"`
+	Owner              string `json:"owner,omitempty"`
+	CostCenter         string `json:"costCenter,omitempty"`
+	DataClassification string `json:"dataClassification,omitempty"`
+}
+
+// Load reads, decodes and validates the configuration file at path.
+func Load(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	config, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// Parse decodes and validates configuration content. Unknown fields are rejected.
+func Parse(content []byte) (*Config, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	var config Config
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to decode: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Validate reports every missing or invalid field.
+func (c *Config) Validate() error {
+	errs := []error{
+		check("version", c.Version == Version, "must be %d, got %d", Version, c.Version),
+		check("stackId", stackIDPattern.MatchString(c.StackID), "must start with a letter and contain only letters, digits and hyphens, got %q", c.StackID),
+		check("account", c.Account == "" || accountPattern.MatchString(c.Account), "must be a 12 digit AWS account ID, got %q", c.Account),
+		check("region", regionPattern.MatchString(c.Region), "must be an AWS region such as us-east-1, got %q", c.Region),
+		check("layout", c.Layout == "" || c.Layout == LayoutSingle || c.Layout == LayoutSplit, "must be %q or %q, got %q", LayoutSingle, LayoutSplit, c.Layout),
+		check("namePrefix", namePrefixPattern.MatchString(c.NamePrefix), "must be 2-24 lower-case letters, digits and hyphens, got %q", c.NamePrefix),
+	}
+
+	errs = append(errs, c.validateSections()...)
+	for i, model := range c.FoundationModels {
+		errs = append(errs, check(fmt.Sprintf("foundationModels[%d]", i), model != "", "must not be empty"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateSections checks the environment and every optional section that is set.
+func (c *Config) validateSections() []error {
+	errs := c.Environment.validate()
+	if c.Network != nil {
+		errs = append(errs, c.Network.validate()...)
+		errs = append(errs, check("account", c.Account != "" || c.Network.ExistingVpc == nil, "must be set to look up network.existingVpc"))
+	}
+	if c.LoadBalancer != nil {
+		errs = append(errs, c.LoadBalancer.validate(c.Region)...)
+	}
+	if c.Domains != nil {
+		errs = append(errs, c.Domains.validate()...)
+	}
+	if c.Database != nil {
+		errs = append(errs, c.Database.validate()...)
+	}
+	if c.Tags != nil {
+		errs = append(errs, c.Tags.validate()...)
+	}
+	return errs
+}
+
+// validate checks the environment profile and its overrides.
+func (e *Environment) validate() []error {
+	_, profileErr := stack.EnvironmentByName(e.Profile)
+	minCapacity, maxCapacity := e.capacity()
+
+	errs := []error{
+		check("environment.profile", profileErr == nil, "must be one of %q, %q or %q, got %q", stack.EnvironmentDev, stack.EnvironmentStaging, stack.EnvironmentProd, e.Profile),
+		check("environment.databaseMinCapacity", within(e.DatabaseMinCapacity, 0, 256), "must be between 0 and 256 ACUs"),
+		check("environment.databaseMaxCapacity", within(e.DatabaseMaxCapacity, 1, 256), "must be between 1 and 256 ACUs"),
+		check("environment.databaseMinCapacity", minCapacity <= maxCapacity, "must not exceed databaseMaxCapacity, got %g above %g", minCapacity, maxCapacity),
+		check("environment.taskCpu", within(e.TaskCPU, 1, math.MaxFloat64), "must be positive"),
+		check("environment.taskMemoryMiB", within(e.TaskMemoryMiB, 1, math.MaxFloat64), "must be positive"),
+		check("environment.desiredCount", within(e.DesiredCount, 0, math.MaxFloat64), "must not be negative"),
+		check("environment.logRetentionDays", e.logRetentionSupported(), "must be a retention period supported by CloudWatch Logs"),
+	}
+	if e.DatabaseReaders != nil {
+		for i, reader := range *e.DatabaseReaders {
+			field := fmt.Sprintf("environment.databaseReaders[%d]", i)
+			errs = append(errs,
+				check(field+".instanceType", reader.InstanceType == "" || readerClassPattern.MatchString(reader.InstanceType), "must be an instance class such as r6g.large, got %q", reader.InstanceType),
+				check(field+".promotionTier", reader.PromotionTier >= 0 && reader.PromotionTier <= 15, "must be between 0 and 15, got %d", reader.PromotionTier),
+			)
+		}
+	}
+	return errs
+}
+
+// capacity returns the Aurora capacity range once the overrides are applied to the profile. An unknown profile,
+// which is reported on its own, yields an empty range.
+func (e *Environment) capacity() (float64, float64) {
+	environment, err := stack.EnvironmentByName(e.Profile)
+	if err != nil {
+		return 0, 0
+	}
+	e.applyDatabase(environment)
+	return environment.DatabaseMinCapacity, environment.DatabaseMaxCapacity
+}
+
+// logRetentionSupported reports whether the retention override, when set, is a CloudWatch Logs period.
+func (e *Environment) logRetentionSupported() bool {
+	if e.LogRetentionDays == nil {
+		return true
+	}
+	_, ok := stack.LogRetentionByDays(*e.LogRetentionDays)
+	return ok
+}
+
+// validate checks the network egress mode is known, that workloads without egress can reach AWS services and
+// that an existing VPC is fully identified.
+func (n *Network) validate() []error {
+	_, egressErr := stack.ParseNetworkEgress(n.Egress)
+	_, flowLogsErr := stack.ParseFlowLogDestination(n.FlowLogs)
+
+	errs := []error{
+		check("network.egress", egressErr == nil, "must be one of %q, %q, %q or %q, got %q",
+			stack.NetworkEgressPublic, stack.NetworkEgressNATGateway, stack.NetworkEgressNATInstance, stack.NetworkEgressNone, n.Egress),
+		check("network.flowLogs", flowLogsErr == nil, "must be %q or %q, got %q", stack.FlowLogsCloudWatch, stack.FlowLogsS3, n.FlowLogs),
+		check("network.vpcEndpoints", n.VpcEndpoints || n.Egress != string(stack.NetworkEgressNone), "must be true when egress is %q", stack.NetworkEgressNone),
+	}
+	if n.ExistingVpc != nil {
+		errs = append(errs,
+			check("network.egress", n.Egress == "", "must not be set with existingVpc, got %q", n.Egress),
+			check("network.dualStack", !n.DualStack, "must not be set with existingVpc"),
+			check("network.existingVpc.vpcId", vpcIDPattern.MatchString(n.ExistingVpc.VpcID), "must be a VPC ID such as vpc-0123456789abcdef0, got %q", n.ExistingVpc.VpcID),
+			check("network.existingVpc.workloadSubnetGroup", optionalSubnetName(n.ExistingVpc.WorkloadSubnetGroup), "must be a subnet group name, got %q", n.ExistingVpc.WorkloadSubnetGroup),
+			check("network.existingVpc.databaseSubnetGroup", optionalSubnetName(n.ExistingVpc.DatabaseSubnetGroup), "must be a subnet group name, got %q", n.ExistingVpc.DatabaseSubnetGroup),
+		)
+	}
+	return errs
+}
+
+// optionalSubnetName reports whether a subnet group name is unset or well formed.
+func optionalSubnetName(name string) bool {
+	return name == "" || subnetNamePattern.MatchString(name)
+}
+
+// validate checks the HTTPS settings when they are set.
+func (l *LoadBalancer) validate(region string) []error {
+	if l.HTTPS == nil {
+		return nil
+	}
+	return l.HTTPS.validate(region)
+}
+
+// validate checks the domain name and that the certificate is either requested through a hosted zone the domain
+// belongs to or imported from the stack's region.
+func (h *HTTPS) validate(region string) []error {
+	errs := []error{
+		check("loadBalancer.https.domainName", domainNamePattern.MatchString(h.DomainName), "must be a lower-case domain name, got %q", h.DomainName),
+		check("loadBalancer.https", h.HostedZone != nil || h.CertificateArn != "", "must set hostedZone or certificateArn"),
+	}
+	if h.HostedZone != nil {
+		errs = append(errs, h.HostedZone.validate("loadBalancer.https.hostedZone")...)
+		errs = append(errs, check("loadBalancer.https.domainName", inZone(h.DomainName, h.HostedZone.Name), "must belong to hosted zone %q, got %q", h.HostedZone.Name, h.DomainName))
+	}
+	if h.CertificateArn != "" {
+		match := certificatePattern.FindStringSubmatch(h.CertificateArn)
+		errs = append(errs,
+			check("loadBalancer.https.certificateArn", match != nil, "must be an ACM certificate ARN, got %q", h.CertificateArn),
+			check("loadBalancer.https.certificateArn", match == nil || match[1] == region, "must be in region %q, got %q", region, h.CertificateArn),
+		)
+	}
+	return errs
+}
+
+// validate checks that every custom domain name is well formed, distinct and in the hosted zone.
+func (d *Domains) validate() []error {
+	if d.HostedZone == nil {
+		return []error{check("domains.hostedZone", false, "must be set")}
+	}
+
+	errs := d.HostedZone.validate("domains.hostedZone")
+	errs = append(errs, check("domains", d.API != "" || d.Auth != "" || d.Frontend != "", "must set api, auth or frontend"))
+	seen := map[string]bool{}
+	for _, name := range []struct{ field, value string }{{"api", d.API}, {"auth", d.Auth}, {"frontend", d.Frontend}} {
+		if name.value == "" {
+			continue
+		}
+		field := "domains." + name.field
+		errs = append(errs,
+			check(field, domainNamePattern.MatchString(name.value), "must be a lower-case domain name, got %q", name.value),
+			check(field, inZone(name.value, d.HostedZone.Name), "must belong to hosted zone %q, got %q", d.HostedZone.Name, name.value),
+			check(field, !seen[name.value], "must differ from the other domain names, got %q", name.value),
+		)
+		seen[name.value] = true
+	}
+	return errs
+}
+
+// validate checks the hosted zone ID and name; field is the zone's path in the file.
+func (h *HostedZone) validate(field string) []error {
+	return []error{
+		check(field+".id", hostedZonePattern.MatchString(h.ID), "must be a hosted zone ID such as Z0123456789ABCDEFGHIJ, got %q", h.ID),
+		check(field+".name", domainNamePattern.MatchString(h.Name), "must be a lower-case domain name, got %q", h.Name),
+	}
+}
+
+// inZone reports whether the domain name is the zone's apex or one of its subdomains.
+func inZone(domainName, zoneName string) bool {
+	return domainName == zoneName || strings.HasSuffix(domainName, "."+zoneName)
+}
+
+// validate checks the database and table names are usable SQL identifiers, the vector stores are supported and the
+// migration target is a version.
+func (d *Database) validate() []error {
+	errs := []error{
+		check("database.name", d.Name == "" || identifierPattern.MatchString(d.Name), "must be a lower-case SQL identifier, got %q", d.Name),
+		check("database.tableName", d.TableName == "" || identifierPattern.MatchString(d.TableName), "must be a lower-case SQL identifier, got %q", d.TableName),
+		check("database.tableName", d.TableName == "" || len(d.VectorStores) == 0, "must not be set with vectorStores"),
+	}
+	tableNames := make(map[string]bool, len(d.VectorStores))
+	for i, store := range d.VectorStores {
+		field := fmt.Sprintf("database.vectorStores[%d]", i)
+		errs = append(errs, store.validate(field)...)
+		errs = append(errs, check(field+".tableName", !tableNames[store.TableName], "must be unique, got %q again", store.TableName))
+		tableNames[store.TableName] = true
+	}
+	if d.Migrations != nil {
+		errs = append(errs, check("database.migrations.targetVersion", d.Migrations.TargetVersion >= 0, "must not be negative, got %d", d.Migrations.TargetVersion))
+	}
+	if d.Backup != nil {
+		errs = append(errs, d.Backup.validate()...)
+	}
+	errs = append(errs, check("database.restoreSnapshotIdentifier", d.RestoreSnapshotIdentifier == "" || snapshotPattern.MatchString(d.RestoreSnapshotIdentifier),
+		"must be a cluster snapshot name or ARN, got %q", d.RestoreSnapshotIdentifier))
+	return errs
+}
+
+// validate checks the retention period, the window formats, that the windows do not overlap and the backup plan.
+func (b *Backup) validate() []error {
+	backupWindow := cmp.Or(b.PreferredBackupWindow, stack.DefaultDatabaseBackupWindow)
+	maintenanceWindow := cmp.Or(b.PreferredMaintenanceWindow, stack.DefaultDatabaseMaintenanceWindow)
+
+	errs := []error{
+		check("database.backup.retentionDays", b.RetentionDays >= 0 && b.RetentionDays <= 35, "must be between 1 and 35 days, or 0 for the profile's, got %d", b.RetentionDays),
+		check("database.backup.preferredBackupWindow", b.PreferredBackupWindow == "" || backupWindowPattern.MatchString(b.PreferredBackupWindow),
+			"must be a UTC window such as \"03:00-04:00\", got %q", b.PreferredBackupWindow),
+		check("database.backup.preferredMaintenanceWindow", b.PreferredMaintenanceWindow == "" || maintenanceWindowPattern.MatchString(b.PreferredMaintenanceWindow),
+			"must be a UTC window such as \"sun:04:30-sun:05:30\", got %q", b.PreferredMaintenanceWindow),
+		check("database.backup.preferredBackupWindow", !windowsOverlap(backupWindow, maintenanceWindow),
+			"must not overlap the maintenance window %q, got %q", maintenanceWindow, backupWindow),
+	}
+	if b.Plan != nil {
+		errs = append(errs, b.Plan.validate()...)
+	}
+	return errs
+}
+
+// validate checks the plan's retention periods, copy destination and vault lock.
+func (p *BackupPlan) validate() []error {
+	errs := []error{
+		check("database.backup.plan.retentionDays", p.RetentionDays >= 1, "must be at least 1 day, got %d", p.RetentionDays),
+		check("database.backup.plan.retentionDays", !p.ContinuousBackup || p.RetentionDays <= 35, "must be at most 35 days with continuousBackup, got %d", p.RetentionDays),
+		check("database.backup.plan.copyToVaultArn", p.CopyToVaultArn == "" || backupVaultPattern.MatchString(p.CopyToVaultArn), "must be a backup vault ARN, got %q", p.CopyToVaultArn),
+		check("database.backup.plan.copyRetentionDays", p.CopyRetentionDays >= 0, "must not be negative, got %d", p.CopyRetentionDays),
+		check("database.backup.plan.copyRetentionDays", p.CopyRetentionDays == 0 || p.CopyToVaultArn != "", "must not be set without copyToVaultArn"),
+	}
+	if p.VaultLock != nil {
+		errs = append(errs, p.VaultLock.validate(p.RetentionDays)...)
+	}
+	return errs
+}
+
+// validate checks the lock's bounds are ordered, its grace period is long enough and they admit the plan's
+// retention.
+func (l *VaultLock) validate(retentionDays int) []error {
+	return []error{
+		check("database.backup.plan.vaultLock.minRetentionDays", l.MinRetentionDays >= 1, "must be at least 1 day, got %d", l.MinRetentionDays),
+		check("database.backup.plan.vaultLock.maxRetentionDays", l.MaxRetentionDays == 0 || l.MaxRetentionDays >= l.MinRetentionDays,
+			"must not be below minRetentionDays, got %d", l.MaxRetentionDays),
+		check("database.backup.plan.vaultLock.changeableForDays", l.ChangeableForDays == 0 || l.ChangeableForDays >= 3, "must be at least 3 days, got %d", l.ChangeableForDays),
+		check("database.backup.plan.retentionDays", retentionDays >= l.MinRetentionDays && (l.MaxRetentionDays == 0 || retentionDays <= l.MaxRetentionDays),
+			"must be within the vault lock's retention range, got %d", retentionDays),
+	}
+}
+
+// validate checks the table name is a usable SQL identifier and the model, index type and metric are supported.
+func (v *VectorStore) validate(field string) []error {
+	_, knownModel := stack.EmbeddingModelDimensions[v.EmbeddingModel]
+	_, indexTypeErr := stack.ParseVectorIndexType(v.IndexType)
+	_, metricErr := stack.ParseDistanceMetric(v.DistanceMetric)
+
+	return []error{
+		check(field+".tableName", identifierPattern.MatchString(v.TableName), "must be a lower-case SQL identifier, got %q", v.TableName),
+		check(field+".embeddingModel", v.EmbeddingModel == "" || knownModel, "must be a supported Bedrock embedding model, got %q", v.EmbeddingModel),
+		check(field+".indexType", indexTypeErr == nil, "must be %q or %q, got %q", stack.VectorIndexHNSW, stack.VectorIndexIVFFlat, v.IndexType),
+		check(field+".distanceMetric", metricErr == nil, "must be %q, %q or %q, got %q",
+			stack.DistanceMetricCosine, stack.DistanceMetricEuclidean, stack.DistanceMetricInnerProduct, v.DistanceMetric),
+	}
+}
+
+// validate checks the tag values only use characters AWS accepts in tags.
+func (t *Tags) validate() []error {
+	const format = "must be at most 256 letters, digits, spaces and _.:/=+-@, got %q"
+
+	return []error{
+		check("tags.project", tagValuePattern.MatchString(t.Project), format, t.Project),
+		check("tags.environment", tagValuePattern.MatchString(t.Environment), format, t.Environment),
+		check("tags.owner", tagValuePattern.MatchString(t.Owner), format, t.Owner),
+		check("tags.costCenter", tagValuePattern.MatchString(t.CostCenter), format, t.CostCenter),
+		check("tags.dataClassification", tagValuePattern.MatchString(t.DataClassification), format, t.DataClassification),
+	}
+}
+
+// check returns a field error when ok is false.
+func check(field string, ok bool, format string, args ...interface{}) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...))
+}
+
+// within reports whether an optional value, when set, lies in [minimum, maximum].
+func within(value *float64, minimum, maximum float64) bool {
+	return value == nil || (*value >= minimum && *value <= maximum)
+}
+
+// windowsOverlap reports whether the daily backup window overlaps the weekly maintenance window on any day. Windows
+// that do not parse never overlap; their format is reported separately.
+func windowsOverlap(backupWindow, maintenanceWindow string) bool {
+	if !backupWindowPattern.MatchString(backupWindow) || !maintenanceWindowPattern.MatchString(maintenanceWindow) {
+		return false
+	}
+	backupStart, backupEnd := dailyWindow(backupWindow)
+	maintenanceStart, maintenanceEnd := weeklyWindow(maintenanceWindow)
+
+	// Starting the day before the maintenance week catches backups that run past midnight into it, and ending the
+	// day after catches maintenance that runs past the end of the week.
+	for day := -1; day <= 7; day++ {
+		start, end := day*minutesPerDay+backupStart, day*minutesPerDay+backupEnd
+		if start < maintenanceEnd && maintenanceStart < end {
+			return true
+		}
+	}
+	return false
+}
+
+// dailyWindow returns the minutes into the day a window such as "03:00-04:00" starts and ends at. A window that
+// ends before it starts ends the next day.
+func dailyWindow(window string) (int, int) {
+	from, to, _ := strings.Cut(window, "-")
+	start, end := clockMinutes(from), clockMinutes(to)
+	if end <= start {
+		end += minutesPerDay
+	}
+	return start, end
+}
+
+// weeklyWindow returns the minutes into the week, from Monday, a window such as "sun:04:30-sun:05:30" starts and
+// ends at. A window that ends before it starts ends the next week.
+func weeklyWindow(window string) (int, int) {
+	from, to, _ := strings.Cut(window, "-")
+	start, end := weekMinutes(from), weekMinutes(to)
+	if end <= start {
+		end += minutesPerWeek
+	}
+	return start, end
+}
+
+// weekMinutes returns the minutes into the week of a time such as "sun:04:30".
+func weekMinutes(value string) int {
+	weekday, clock, _ := strings.Cut(value, ":")
+	return weekdays[weekday]*minutesPerDay + clockMinutes(clock)
+}
+
+// clockMinutes returns the minutes into the day of a time such as "04:30".
+func clockMinutes(clock string) int {
+	hour, minute, _ := strings.Cut(clock, ":")
+	hours, _ := strconv.Atoi(hour)
+	minutes, _ := strconv.Atoi(minute)
+	return hours*60 + minutes
+}
+
+// SplitStacks reports whether the application is deployed as separate network, data, compute and edge stacks.
+func (c *Config) SplitStacks() bool {
+	return c.Layout == LayoutSplit
+}
+
+// AppStackProps converts the configuration into stack properties.
+func (c *Config) AppStackProps() (*stack.AppStackProps, error) {
+	environment, err := stack.EnvironmentByName(c.Environment.Profile)
+	if err != nil {
+		return nil, err
+	}
+	c.Environment.apply(environment)
+
+	props := &stack.AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Region: jsii.String(c.Region),
+			},
+		},
+		Environment:      environment,
+		Naming:           stack.NewNaming(c.NamePrefix),
+		FoundationModels: c.FoundationModels,
+	}
+	if c.Account != "" {
+		props.Env.Account = jsii.String(c.Account)
+	}
+	if c.Network != nil {
+		if props.Networking, err = c.Network.networkConfig(); err != nil {
+			return nil, err
+		}
+	}
+	if c.LoadBalancer != nil {
+		props.LoadBalancer = c.LoadBalancer.loadBalancerConfig()
+	}
+	if d := c.Domains; d != nil {
+		props.Domains = &stack.DomainConfig{
+			HostedZone:         stack.HostedZoneConfig{ID: d.HostedZone.ID, Name: d.HostedZone.Name},
+			APIDomainName:      d.API,
+			AuthDomainName:     d.Auth,
+			FrontendDomainName: d.Frontend,
+		}
+	}
+	if c.Database != nil {
+		c.Database.applyTo(props)
+	}
+	if c.Tags != nil {
+		props.TagSchema = &stack.TagSchema{
+			Project:            c.Tags.Project,
+			Environment:        c.Tags.Environment,
+			Owner:              c.Tags.Owner,
+			CostCenter:         c.Tags.CostCenter,
+			DataClassification: c.Tags.DataClassification,
+		}
+	}
+
+	return props, nil
+}
+
+// applyTo sets the database names, vector stores and migration settings of the stack properties.
+func (d *Database) applyTo(props *stack.AppStackProps) {
+	props.DatabaseName = d.Name
+	props.VectorTableName = d.TableName
+	for _, store := range d.VectorStores {
+		props.VectorStores = append(props.VectorStores, stack.VectorStoreConfig{
+			TableName:      store.TableName,
+			EmbeddingModel: store.EmbeddingModel,
+			IndexType:      stack.VectorIndexType(store.IndexType),
+			DistanceMetric: stack.DistanceMetric(store.DistanceMetric),
+		})
+	}
+	if m := d.Migrations; m != nil {
+		props.Migrations = &stack.MigrationConfig{TargetVersion: m.TargetVersion, DryRun: m.DryRun}
+	}
+	if d.Backup != nil {
+		props.DatabaseBackup = d.Backup.backupConfig()
+	}
+	props.RestoreSnapshotIdentifier = d.RestoreSnapshotIdentifier
+}
+
+// backupConfig converts the backup section into the stack's backup settings.
+func (b *Backup) backupConfig() *stack.DatabaseBackupConfig {
+	backup := &stack.DatabaseBackupConfig{
+		RetentionDays:              b.RetentionDays,
+		PreferredBackupWindow:      b.PreferredBackupWindow,
+		PreferredMaintenanceWindow: b.PreferredMaintenanceWindow,
+	}
+	if p := b.Plan; p != nil {
+		backup.Plan = &stack.BackupPlanConfig{
+			RetentionDays:     p.RetentionDays,
+			ContinuousBackup:  p.ContinuousBackup,
+			CopyToVaultArn:    p.CopyToVaultArn,
+			CopyRetentionDays: p.CopyRetentionDays,
+		}
+		if l := p.VaultLock; l != nil {
+			backup.Plan.VaultLock = &stack.VaultLockConfig{
+				MinRetentionDays:  l.MinRetentionDays,
+				MaxRetentionDays:  l.MaxRetentionDays,
+				ChangeableForDays: l.ChangeableForDays,
+			}
+		}
+	}
+	return backup
+}
+
+// networkConfig converts the network section into the stack's network settings.
+func (n *Network) networkConfig() (*stack.NetworkConfig, error) {
+	egress, err := stack.ParseNetworkEgress(n.Egress)
+	if err != nil {
+		return nil, err
+	}
+	flowLogs, err := stack.ParseFlowLogDestination(n.FlowLogs)
+	if err != nil {
+		return nil, err
+	}
+
+	networking := &stack.NetworkConfig{
+		Egress:       egress,
+		VpcEndpoints: n.VpcEndpoints,
+		FlowLogs:     flowLogs,
+		DualStack:    n.DualStack,
+	}
+	if existing := n.ExistingVpc; existing != nil {
+		networking.ExistingVpc = &stack.ExistingVpcConfig{
+			VpcID:               existing.VpcID,
+			WorkloadSubnetGroup: existing.WorkloadSubnetGroup,
+			DatabaseSubnetGroup: existing.DatabaseSubnetGroup,
+		}
+	}
+	return networking, nil
+}
+
+// loadBalancerConfig converts the load balancer section into the stack's load balancer settings.
+func (l *LoadBalancer) loadBalancerConfig() *stack.LoadBalancerConfig {
+	loadBalancer := &stack.LoadBalancerConfig{Internal: l.Internal}
+	if https := l.HTTPS; https != nil {
+		loadBalancer.HTTPS = &stack.HTTPSConfig{
+			DomainName:     https.DomainName,
+			CertificateArn: https.CertificateArn,
+		}
+		if https.HostedZone != nil {
+			loadBalancer.HTTPS.HostedZone = &stack.HostedZoneConfig{ID: https.HostedZone.ID, Name: https.HostedZone.Name}
+		}
+	}
+	return loadBalancer
+}
+
+// apply overrides the profile values with the ones set in the file.
+func (e *Environment) apply(environment *stack.EnvironmentConfig) {
+	e.applyDatabase(environment)
+	if e.TaskCPU != nil {
+		environment.TaskCPU = *e.TaskCPU
+	}
+	if e.TaskMemoryMiB != nil {
+		environment.TaskMemoryMiB = *e.TaskMemoryMiB
+	}
+	if e.DesiredCount != nil {
+		environment.DesiredCount = *e.DesiredCount
+	}
+	if e.LogRetentionDays != nil {
+		environment.LogRetention, _ = stack.LogRetentionByDays(*e.LogRetentionDays)
+	}
+	if e.DataProtection != nil {
+		environment.DataProtection = *e.DataProtection
+	}
+	if e.EnforceSecurityPolicy != nil {
+		environment.EnforceSecurityPolicy = *e.EnforceSecurityPolicy
+	}
+}
+
+// applyDatabase overrides the Aurora capacity range and readers of the profile.
+func (e *Environment) applyDatabase(environment *stack.EnvironmentConfig) {
+	if e.DatabaseMinCapacity != nil {
+		environment.DatabaseMinCapacity = *e.DatabaseMinCapacity
+	}
+	if e.DatabaseMaxCapacity != nil {
+		environment.DatabaseMaxCapacity = *e.DatabaseMaxCapacity
+	}
+	if e.DatabaseReaders != nil {
+		environment.DatabaseReaders = []stack.DatabaseReaderConfig{}
+		for _, reader := range *e.DatabaseReaders {
+			environment.DatabaseReaders = append(environment.DatabaseReaders, stack.DatabaseReaderConfig{
+				InstanceType:  reader.InstanceType,
+				PromotionTier: reader.PromotionTier,
+			})
+		}
+	}
+}
@@ -0,0 +1,551 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"code-refactoring-infra/stack"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+)
+
+func TestLoad_CheckedInConfigs(t *testing.T) {
+	for _, path := range []string{"dev.json", "staging.json", "prod.json"} {
+		t.Run(path, func(t *testing.T) {
+			// Act
+			cfg, err := Load(path)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Load(%q) returned error: %v", path, err)
+			}
+			if _, err := cfg.AppStackProps(); err != nil {
+				t.Errorf("AppStackProps() returned error: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	// Act
+	_, err := Load("missing.json")
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "missing.json") {
+		t.Errorf("expected error naming the missing file, got %v", err)
+	}
+}
+
+func TestParse_RejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr []string
+	}{
+		{
+			name:    "missing fields",
+			content: `{}`,
+			wantErr: []string{"version: must be 1", "stackId:", "region:", "namePrefix:", "environment.profile:"},
+		},
+		{
+			name:    "unknown field",
+			content: `{"version": 1, "stackName": "Typo"}`,
+			wantErr: []string{`unknown field "stackName"`},
+		},
+		{
+			name: "no egress without endpoints",
+			content: `{
+				"version": 1,
+				"stackId": "CodeRefactorInfra",
+				"region": "us-east-1",
+				"namePrefix": "code-refactor",
+				"environment": {"profile": "dev"},
+				"network": {"egress": "none"}
+			}`,
+			wantErr: []string{"network.vpcEndpoints: must be true"},
+		},
+		{
+			name: "incomplete existing VPC",
+			content: `{
+				"version": 1,
+				"stackId": "CodeRefactorInfra",
+				"region": "us-east-1",
+				"namePrefix": "code-refactor",
+				"environment": {"profile": "dev"},
+				"network": {"egress": "nat-gateway", "dualStack": true, "existingVpc": {"vpcId": "shared", "databaseSubnetGroup": "data tier"}}
+			}`,
+			wantErr: []string{
+				"account: must be set to look up network.existingVpc",
+				"network.egress: must not be set with existingVpc",
+				"network.dualStack: must not be set with existingVpc",
+				"network.existingVpc.vpcId:",
+				"network.existingVpc.databaseSubnetGroup:",
+			},
+		},
+		{
+			name: "HTTPS without a certificate source",
+			content: `{
+				"version": 1,
+				"stackId": "CodeRefactorInfra",
+				"region": "us-east-1",
+				"namePrefix": "code-refactor",
+				"environment": {"profile": "dev"},
+				"loadBalancer": {"https": {"domainName": "API.example.com"}}
+			}`,
+			wantErr: []string{
+				"loadBalancer.https.domainName: must be a lower-case domain name",
+				"loadBalancer.https: must set hostedZone or certificateArn",
+			},
+		},
+		{
+			name: "HTTPS certificate outside the zone and region",
+			content: `{
+				"version": 1,
+				"stackId": "CodeRefactorInfra",
+				"region": "us-east-1",
+				"namePrefix": "code-refactor",
+				"environment": {"profile": "dev"},
+				"loadBalancer": {"https": {
+					"domainName": "origin.example.org",
+					"hostedZone": {"id": "zone", "name": "example.com"},
+					"certificateArn": "arn:aws:acm:eu-west-1:123456789012:certificate/0a1b2c3d-4e5f-6a7b-8c9d-0e1f2a3b4c5d"
+				}}
+			}`,
+			wantErr: []string{
+				"loadBalancer.https.hostedZone.id:",
+				"loadBalancer.https.domainName: must belong to hosted zone",
+				"loadBalancer.https.certificateArn: must be in region",
+			},
+		},
+		{
+			name: "custom domains outside the hosted zone",
+			content: `{
+				"version": 1,
+				"stackId": "CodeRefactorInfra",
+				"region": "us-east-1",
+				"namePrefix": "code-refactor",
+				"environment": {"profile": "dev"},
+				"domains": {
+					"hostedZone": {"id": "Z0123456789ABCDEFGHIJ", "name": "example.com"},
+					"api": "api.example.org",
+					"auth": "app.example.com",
+					"frontend": "app.example.com"
+				}
+			}`,
+			wantErr: []string{
+				"domains.api: must belong to hosted zone",
+				"domains.frontend: must differ from the other domain names",
+			},
+		},
+		{
+			name: "custom domains without names",
+			content: `{
+				"version": 1,
+				"stackId": "CodeRefactorInfra",
+				"region": "us-east-1",
+				"namePrefix": "code-refactor",
+				"environment": {"profile": "dev"},
+				"domains": {"hostedZone": {"id": "Z0123456789ABCDEFGHIJ", "name": "example.com"}}
+			}`,
+			wantErr: []string{"domains: must set api, auth or frontend"},
+		},
+		{
+			name: "invalid vector stores",
+			content: `{
+				"version": 1,
+				"stackId": "CodeRefactorInfra",
+				"region": "us-east-1",
+				"namePrefix": "code-refactor",
+				"environment": {"profile": "dev"},
+				"database": {
+					"tableName": "embeddings",
+					"vectorStores": [
+						{"tableName": "docs", "embeddingModel": "amazon.titan-text-lite-v1", "indexType": "diskann", "distanceMetric": "hamming"},
+						{"tableName": "docs"},
+						{"tableName": "Images"}
+					]
+				}
+			}`,
+			wantErr: []string{
+				"database.tableName: must not be set with vectorStores",
+				"database.vectorStores[0].embeddingModel:",
+				"database.vectorStores[0].indexType:",
+				"database.vectorStores[0].distanceMetric:",
+				"database.vectorStores[1].tableName: must be unique",
+				"database.vectorStores[2].tableName:",
+			},
+		},
+		{
+			name: "invalid backup",
+			content: `{
+				"version": 1,
+				"stackId": "CodeRefactorInfra",
+				"region": "us-east-1",
+				"namePrefix": "code-refactor",
+				"environment": {"profile": "dev"},
+				"database": {
+					"backup": {
+						"retentionDays": 40,
+						"preferredBackupWindow": "3:00-4:00",
+						"preferredMaintenanceWindow": "sunday",
+						"plan": {
+							"retentionDays": 60,
+							"continuousBackup": true,
+							"vaultLock": {"minRetentionDays": 90, "maxRetentionDays": 30, "changeableForDays": 1},
+							"copyToVaultArn": "code-refactor-dr"
+						}
+					},
+					"restoreSnapshotIdentifier": "snapshot_1"
+				}
+			}`,
+			wantErr: []string{
+				"database.backup.retentionDays: must be between 1 and 35 days",
+				"database.backup.preferredBackupWindow:",
+				"database.backup.preferredMaintenanceWindow:",
+				"database.backup.plan.retentionDays: must be at most 35 days with continuousBackup",
+				"database.backup.plan.copyToVaultArn:",
+				"database.backup.plan.vaultLock.maxRetentionDays: must not be below minRetentionDays",
+				"database.backup.plan.vaultLock.changeableForDays: must be at least 3 days",
+				"database.backup.plan.retentionDays: must be within the vault lock's retention range",
+				"database.restoreSnapshotIdentifier:",
+			},
+		},
+		{
+			name: "backup window overlapping the default maintenance window",
+			content: `{
+				"version": 1,
+				"stackId": "CodeRefactorInfra",
+				"region": "us-east-1",
+				"namePrefix": "code-refactor",
+				"environment": {"profile": "dev"},
+				"database": {"backup": {"preferredBackupWindow": "04:00-05:00"}}
+			}`,
+			wantErr: []string{`database.backup.preferredBackupWindow: must not overlap the maintenance window "sun:04:30-sun:05:30"`},
+		},
+		{
+			name: "backup window overlapping a maintenance window across midnight",
+			content: `{
+				"version": 1,
+				"stackId": "CodeRefactorInfra",
+				"region": "us-east-1",
+				"namePrefix": "code-refactor",
+				"environment": {"profile": "dev"},
+				"database": {"backup": {"preferredBackupWindow": "23:00-00:15", "preferredMaintenanceWindow": "sun:23:30-mon:00:30"}}
+			}`,
+			wantErr: []string{"database.backup.preferredBackupWindow: must not overlap the maintenance window"},
+		},
+		{
+			name: "capacity override above the profile maximum",
+			content: `{
+				"version": 1,
+				"stackId": "CodeRefactorInfra",
+				"region": "us-east-1",
+				"namePrefix": "code-refactor",
+				"environment": {"profile": "dev", "databaseMinCapacity": 8}
+			}`,
+			wantErr: []string{"environment.databaseMinCapacity: must not exceed databaseMaxCapacity, got 8 above 4"},
+		},
+		{
+			name: "invalid values",
+			content: `{
+				"version": 1,
+				"stackId": "CodeRefactorInfra",
+				"account": "1234",
+				"region": "mars-1",
+				"layout": "nested",
+				"namePrefix": "Code_Refactor",
+				"environment": {"profile": "prod", "databaseMinCapacity": 8, "databaseMaxCapacity": 4, "logRetentionDays": 10,
+					"databaseReaders": [{"instanceType": "large", "promotionTier": 16}]},
+				"network": {"egress": "internet", "flowLogs": "kinesis"},
+				"database": {"tableName": "vector-store", "migrations": {"targetVersion": -1}},
+				"tags": {"owner": "platform#team"}
+			}`,
+			wantErr: []string{
+				"account:",
+				"region:",
+				"layout:",
+				"namePrefix:",
+				"environment.databaseMinCapacity: must not exceed databaseMaxCapacity",
+				"environment.logRetentionDays:",
+				"environment.databaseReaders[0].instanceType:",
+				"environment.databaseReaders[0].promotionTier: must be between 0 and 15",
+				"network.egress:",
+				"network.flowLogs:",
+				"database.tableName:",
+				"database.migrations.targetVersion: must not be negative",
+				"tags.owner:",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := Parse([]byte(tt.content))
+
+			// Assert
+			if err == nil {
+				t.Fatal("expected validation error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not mention %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_AppStackProps(t *testing.T) {
+	// Arrange
+	cfg, err := Parse([]byte(`{
+		"version": 1,
+		"stackId": "CodeRefactorInfraProd",
+		"account": "123456789012",
+		"region": "eu-west-1",
+		"namePrefix": "code-refactor-prod",
+		"environment": {"profile": "prod", "databaseMaxCapacity": 32, "logRetentionDays": 90, "dataProtection": false, "enforceSecurityPolicy": false},
+		"network": {"egress": "nat-instance", "vpcEndpoints": true, "flowLogs": "s3", "dualStack": true},
+		"loadBalancer": {"internal": true, "https": {"domainName": "origin.refactor.example.com", "hostedZone": {"id": "Z0123456789ABCDEFGHIJ", "name": "refactor.example.com"}}},
+		"domains": {"hostedZone": {"id": "Z0123456789ABCDEFGHIJ", "name": "refactor.example.com"}, "api": "api.refactor.example.com", "frontend": "refactor.example.com"},
+		"database": {"name": "refactor_db", "tableName": "embeddings", "migrations": {"targetVersion": 2, "dryRun": true}},
+		"foundationModels": ["amazon.titan-embed-text-v2:0"],
+		"tags": {"owner": "platform-team", "costCenter": "cc-1234"}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	// Act
+	props, err := cfg.AppStackProps()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("AppStackProps() returned error: %v", err)
+	}
+	if *props.Env.Region != "eu-west-1" || *props.Env.Account != "123456789012" {
+		t.Errorf("unexpected env %s/%s", *props.Env.Account, *props.Env.Region)
+	}
+	if props.Environment.Name != stack.EnvironmentProd {
+		t.Errorf("Environment.Name = %q", props.Environment.Name)
+	}
+	if props.Environment.DatabaseMaxCapacity != 32 {
+		t.Errorf("DatabaseMaxCapacity = %v, want override 32", props.Environment.DatabaseMaxCapacity)
+	}
+	if props.Environment.DatabaseMinCapacity != stack.ProdEnvironment().DatabaseMinCapacity {
+		t.Errorf("DatabaseMinCapacity = %v, want profile value", props.Environment.DatabaseMinCapacity)
+	}
+	if props.Environment.LogRetention != awslogs.RetentionDays_THREE_MONTHS {
+		t.Errorf("LogRetention = %v", props.Environment.LogRetention)
+	}
+	if props.Environment.RemovalPolicy != awscdk.RemovalPolicy_RETAIN {
+		t.Errorf("RemovalPolicy = %v", props.Environment.RemovalPolicy)
+	}
+	if props.Environment.DataProtection {
+		t.Error("DataProtection = true, want override false")
+	}
+	if props.Environment.EnforceSecurityPolicy {
+		t.Error("EnforceSecurityPolicy = true, want override false")
+	}
+	if props.Networking == nil || props.Networking.Egress != stack.NetworkEgressNATInstance || !props.Networking.VpcEndpoints ||
+		props.Networking.FlowLogs != stack.FlowLogsS3 || !props.Networking.DualStack {
+		t.Errorf("Networking = %+v", props.Networking)
+	}
+	if props.LoadBalancer == nil || !props.LoadBalancer.Internal {
+		t.Errorf("LoadBalancer = %+v", props.LoadBalancer)
+	}
+	if https := props.LoadBalancer.HTTPS; https == nil || https.DomainName != "origin.refactor.example.com" ||
+		https.HostedZone == nil || https.HostedZone.ID != "Z0123456789ABCDEFGHIJ" || https.CertificateArn != "" {
+		t.Errorf("LoadBalancer.HTTPS = %+v", props.LoadBalancer.HTTPS)
+	}
+	if want := (stack.DomainConfig{
+		HostedZone:         stack.HostedZoneConfig{ID: "Z0123456789ABCDEFGHIJ", Name: "refactor.example.com"},
+		APIDomainName:      "api.refactor.example.com",
+		FrontendDomainName: "refactor.example.com",
+	}); props.Domains == nil || *props.Domains != want {
+		t.Errorf("Domains = %+v, want %+v", props.Domains, want)
+	}
+	if props.Networking.ExistingVpc != nil {
+		t.Errorf("ExistingVpc = %+v, want nil", props.Networking.ExistingVpc)
+	}
+	if props.Naming.Prefix != "code-refactor-prod" {
+		t.Errorf("Naming.Prefix = %q", props.Naming.Prefix)
+	}
+	if props.DatabaseName != "refactor_db" || props.VectorTableName != "embeddings" {
+		t.Errorf("unexpected database names %q/%q", props.DatabaseName, props.VectorTableName)
+	}
+	if props.Migrations == nil || *props.Migrations != (stack.MigrationConfig{TargetVersion: 2, DryRun: true}) {
+		t.Errorf("Migrations = %+v", props.Migrations)
+	}
+	if len(props.FoundationModels) != 1 {
+		t.Errorf("FoundationModels = %v", props.FoundationModels)
+	}
+	if props.TagSchema == nil || props.TagSchema.Owner != "platform-team" || props.TagSchema.CostCenter != "cc-1234" {
+		t.Errorf("TagSchema = %+v", props.TagSchema)
+	}
+}
+
+func TestConfig_AppStackProps_ExistingVpc(t *testing.T) {
+	// Arrange
+	cfg, err := Parse([]byte(`{
+		"version": 1,
+		"stackId": "CodeRefactorInfra",
+		"account": "123456789012",
+		"region": "us-east-1",
+		"namePrefix": "code-refactor",
+		"environment": {"profile": "dev"},
+		"network": {"existingVpc": {"vpcId": "vpc-0123456789abcdef0", "workloadSubnetGroup": "App"}}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	// Act
+	props, err := cfg.AppStackProps()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("AppStackProps() returned error: %v", err)
+	}
+	existing := props.Networking.ExistingVpc
+	if existing == nil || existing.VpcID != "vpc-0123456789abcdef0" || existing.WorkloadSubnetGroup != "App" || existing.DatabaseSubnetGroup != "" {
+		t.Errorf("ExistingVpc = %+v", existing)
+	}
+}
+
+func TestConfig_AppStackProps_VectorStores(t *testing.T) {
+	// Arrange
+	cfg, err := Parse([]byte(`{
+		"version": 1,
+		"stackId": "CodeRefactorInfra",
+		"region": "us-east-1",
+		"namePrefix": "code-refactor",
+		"environment": {"profile": "dev"},
+		"database": {"vectorStores": [
+			{"tableName": "documents"},
+			{"tableName": "images", "embeddingModel": "amazon.titan-embed-image-v1", "indexType": "ivfflat", "distanceMetric": "euclidean"}
+		]}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	// Act
+	props, err := cfg.AppStackProps()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("AppStackProps() returned error: %v", err)
+	}
+	want := []stack.VectorStoreConfig{
+		{TableName: "documents"},
+		{TableName: "images", EmbeddingModel: "amazon.titan-embed-image-v1", IndexType: stack.VectorIndexIVFFlat, DistanceMetric: stack.DistanceMetricEuclidean},
+	}
+	if !reflect.DeepEqual(props.VectorStores, want) {
+		t.Errorf("VectorStores = %+v, want %+v", props.VectorStores, want)
+	}
+}
+
+func TestConfig_AppStackProps_DatabaseReaders(t *testing.T) {
+	newProps := func(t *testing.T, environment string) *stack.AppStackProps {
+		cfg, err := Parse([]byte(`{
+			"version": 1,
+			"stackId": "CodeRefactorInfra",
+			"region": "us-east-1",
+			"namePrefix": "code-refactor",
+			"environment": ` + environment + `
+		}`))
+		if err != nil {
+			t.Fatalf("Parse() returned error: %v", err)
+		}
+		props, err := cfg.AppStackProps()
+		if err != nil {
+			t.Fatalf("AppStackProps() returned error: %v", err)
+		}
+		return props
+	}
+
+	t.Run("keeps the profile's readers", func(t *testing.T) {
+		// Act
+		props := newProps(t, `{"profile": "prod"}`)
+
+		// Assert
+		if len(props.Environment.DatabaseReaders) != 1 {
+			t.Errorf("DatabaseReaders = %+v, want the prod reader", props.Environment.DatabaseReaders)
+		}
+	})
+
+	t.Run("replaces the profile's readers", func(t *testing.T) {
+		// Act
+		props := newProps(t, `{"profile": "dev", "databaseReaders": [{"instanceType": "r6g.large", "promotionTier": 2}, {}]}`)
+
+		// Assert
+		want := []stack.DatabaseReaderConfig{{InstanceType: "r6g.large", PromotionTier: 2}, {}}
+		if !reflect.DeepEqual(props.Environment.DatabaseReaders, want) {
+			t.Errorf("DatabaseReaders = %+v, want %+v", props.Environment.DatabaseReaders, want)
+		}
+	})
+
+	t.Run("removes the profile's readers", func(t *testing.T) {
+		// Act
+		props := newProps(t, `{"profile": "prod", "databaseReaders": []}`)
+
+		// Assert
+		if len(props.Environment.DatabaseReaders) != 0 {
+			t.Errorf("DatabaseReaders = %+v, want none", props.Environment.DatabaseReaders)
+		}
+	})
+}
+
+func TestConfig_AppStackProps_DatabaseBackup(t *testing.T) {
+	// Arrange
+	cfg, err := Parse([]byte(`{
+		"version": 1,
+		"stackId": "CodeRefactorInfra-Restore",
+		"region": "us-east-1",
+		"namePrefix": "code-refactor-restore",
+		"environment": {"profile": "dev"},
+		"database": {
+			"backup": {
+				"retentionDays": 14,
+				"preferredBackupWindow": "22:00-22:30",
+				"plan": {
+					"retentionDays": 30,
+					"vaultLock": {"minRetentionDays": 7},
+					"copyToVaultArn": "arn:aws:backup:us-west-2:123456789012:backup-vault:code-refactor-dr",
+					"copyRetentionDays": 90
+				}
+			},
+			"restoreSnapshotIdentifier": "arn:aws:rds:us-east-1:123456789012:cluster-snapshot:rds:code-refactor-cluster-2026-10-01-03-10"
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	// Act
+	props, err := cfg.AppStackProps()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("AppStackProps() returned error: %v", err)
+	}
+	want := &stack.DatabaseBackupConfig{
+		RetentionDays:         14,
+		PreferredBackupWindow: "22:00-22:30",
+		Plan: &stack.BackupPlanConfig{
+			RetentionDays:     30,
+			VaultLock:         &stack.VaultLockConfig{MinRetentionDays: 7},
+			CopyToVaultArn:    "arn:aws:backup:us-west-2:123456789012:backup-vault:code-refactor-dr",
+			CopyRetentionDays: 90,
+		},
+	}
+	if !reflect.DeepEqual(props.DatabaseBackup, want) {
+		t.Errorf("DatabaseBackup = %+v, want %+v", props.DatabaseBackup, want)
+	}
+	if got := props.RestoreSnapshotIdentifier; got != "arn:aws:rds:us-east-1:123456789012:cluster-snapshot:rds:code-refactor-cluster-2026-10-01-03-10" {
+		t.Errorf("RestoreSnapshotIdentifier = %q", got)
+	}
+}
@@ -2,6 +2,10 @@
 package main
 
 import (
+	"log"
+	"path/filepath"
+
+	"code-refactoring-infra/config"
 	"code-refactoring-infra/stack"
 
 	"github.com/aws/aws-cdk-go/awscdk/v2"
@@ -11,33 +15,40 @@ import (
 func main() {
 	app := awscdk.NewApp(nil)
 
-	infrastructureStack := stack.NewAppStack(app, "CodeRefactorInfra", &stack.AppStackProps{
-		StackProps: awscdk.StackProps{
-			Env: &awscdk.Environment{
-				Region: jsii.String("us-east-1"),
-			},
-		},
-	})
-
-	// Output GitHubActionsRoleARN
-	awscdk.NewCfnOutput(infrastructureStack.Stack, jsii.String("GitHubActionsRoleARN"), &awscdk.CfnOutputProps{
-		Value: infrastructureStack.GitHubActionsRoleARN,
-	})
-
-	// Output Account
-	awscdk.NewCfnOutput(infrastructureStack.Stack, jsii.String("Account"), &awscdk.CfnOutputProps{
-		Value: &infrastructureStack.Account,
-	})
-
-	// Output Region
-	awscdk.NewCfnOutput(infrastructureStack.Stack, jsii.String("Region"), &awscdk.CfnOutputProps{
-		Value: &infrastructureStack.Region,
-	})
-
-	// Output RDS Postgres Schema Ensure Lambda ARN
-	awscdk.NewCfnOutput(infrastructureStack.Stack, jsii.String("RDSPostgresSchemaEnsureLambdaARN"), &awscdk.CfnOutputProps{
-		Value: &infrastructureStack.RDSPostgresSchemaEnsureLambdaARN,
-	})
+	// Select the configuration file with `cdk -c config=config/prod.json`
+	configPath := config.DefaultPath
+	if path, ok := app.Node().TryGetContext(jsii.String(config.ContextKey)).(string); ok && path != "" {
+		configPath = path
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	props, err := cfg.AppStackProps()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Check every resource against the security rules; enforced profiles fail synthesis on violations
+	policy := stack.AddSecurityPolicy(app, props.Environment.EnforceSecurityPolicy)
+
+	var outputs *stack.OutputRegistry
+	if cfg.SplitStacks() {
+		outputs = stack.NewSplitAppStacks(app, cfg.StackID, props).Edge.Outputs
+	} else {
+		outputs = stack.NewAppStack(app, cfg.StackID, props).Outputs
+	}
 
 	app.Synth(nil)
+
+	if err := policy.WriteReport(filepath.Join(*app.Outdir(), stack.SecurityReportFile)); err != nil {
+		log.Fatal(err)
+	}
+
+	// Describe the published outputs for CI and the application repositories
+	if err := outputs.WriteSchema(filepath.Join(*app.Outdir(), stack.OutputsSchemaFile)); err != nil {
+		log.Fatal(err)
+	}
 }
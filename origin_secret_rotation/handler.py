@@ -0,0 +1,152 @@
+"""
+Lambda to rotate the origin verification header shared by API Gateway and the load balancer.
+
+Implements the four Secrets Manager rotation steps. While the pending value is being rolled out the
+listener rule accepts both values, so requests keep flowing during the rotation.
+"""
+import os
+import boto3
+from botocore.exceptions import ClientError
+
+CURRENT = "AWSCURRENT"
+PENDING = "AWSPENDING"
+
+
+def create_secret(secrets, secret_id, token):
+    """Store a new random value as the pending version unless it already exists."""
+    try:
+        secrets.get_secret_value(SecretId=secret_id, VersionId=token, VersionStage=PENDING)
+        print(f"Pending version {token} already exists")
+        return
+    except ClientError as e:
+        if e.response["Error"]["Code"] != "ResourceNotFoundException":
+            raise
+
+    password = secrets.get_random_password(PasswordLength=48, ExcludePunctuation=True)
+    secrets.put_secret_value(
+        SecretId=secret_id,
+        ClientRequestToken=token,
+        SecretString=password["RandomPassword"],
+        VersionStages=[PENDING],
+    )
+    print(f"Created pending version {token}")
+
+
+def allow_header_values(values):
+    """Make the listener rule forward requests carrying any of the given header values."""
+    elbv2 = boto3.client("elbv2")
+    elbv2.modify_rule(
+        RuleArn=os.environ["LISTENER_RULE_ARN"],
+        Conditions=[{
+            "Field": "http-header",
+            "HttpHeaderConfig": {
+                "HttpHeaderName": os.environ["HEADER_NAME"],
+                "Values": values,
+            },
+        }],
+    )
+    print(f"Listener rule accepts {len(values)} header value(s)")
+
+
+def send_header_value(value):
+    """Update every integration that sends the header and redeploy the stage."""
+    apigateway = boto3.client("apigateway")
+    rest_api_id = os.environ["REST_API_ID"]
+    parameter = f"integration.request.header.{os.environ['HEADER_NAME']}"
+
+    updated = 0
+    for page in apigateway.get_paginator("get_resources").paginate(restApiId=rest_api_id, embed=["methods"]):
+        for resource in page["items"]:
+            for http_method, method in resource.get("resourceMethods", {}).items():
+                integration = method.get("methodIntegration", {})
+                if parameter not in integration.get("requestParameters", {}):
+                    continue
+                apigateway.update_integration(
+                    restApiId=rest_api_id,
+                    resourceId=resource["id"],
+                    httpMethod=http_method,
+                    patchOperations=[{
+                        "op": "replace",
+                        "path": f"/requestParameters/{parameter}",
+                        "value": f"'{value}'",
+                    }],
+                )
+                updated += 1
+
+    apigateway.create_deployment(
+        restApiId=rest_api_id,
+        stageName=os.environ["STAGE_NAME"],
+        description="Rotate the origin verification header",
+    )
+    print(f"Updated {updated} integration(s) and redeployed stage {os.environ['STAGE_NAME']}")
+
+
+def set_secret(secrets, secret_id, token):
+    """Accept both values at the load balancer, then switch API Gateway to the pending value."""
+    current = secrets.get_secret_value(SecretId=secret_id, VersionStage=CURRENT)["SecretString"]
+    pending = secrets.get_secret_value(SecretId=secret_id, VersionId=token, VersionStage=PENDING)["SecretString"]
+
+    allow_header_values([current, pending])
+    send_header_value(pending)
+
+
+def test_secret(_secrets, _secret_id, token):
+    """Nothing to test: after set_secret both the load balancer and API Gateway use the pending value."""
+    print(f"Pending version {token} is in use")
+
+
+def finish_secret(secrets, secret_id, token):
+    """Promote the pending version and stop accepting the previous value."""
+    metadata = secrets.describe_secret(SecretId=secret_id)
+    current_version = None
+    for version, stages in metadata["VersionIdsToStages"].items():
+        if CURRENT in stages:
+            current_version = version
+            break
+
+    if current_version != token:
+        secrets.update_secret_version_stage(
+            SecretId=secret_id,
+            VersionStage=CURRENT,
+            MoveToVersionId=token,
+            RemoveFromVersionId=current_version,
+        )
+        print(f"Promoted version {token} to {CURRENT}")
+
+    pending = secrets.get_secret_value(SecretId=secret_id, VersionId=token)["SecretString"]
+    allow_header_values([pending])
+
+
+STEPS = {
+    "createSecret": create_secret,
+    "setSecret": set_secret,
+    "testSecret": test_secret,
+    "finishSecret": finish_secret,
+}
+
+
+def lambda_handler(event, _context):
+    """Run one rotation step for the origin secret."""
+    secret_id = event["SecretId"]
+    token = event["ClientRequestToken"]
+    step = event["Step"]
+    print(f"Running {step} for version {token}")
+
+    if step not in STEPS:
+        raise ValueError(f"Unknown rotation step {step}")
+
+    secrets = boto3.client("secretsmanager")
+    metadata = secrets.describe_secret(SecretId=secret_id)
+    if not metadata.get("RotationEnabled"):
+        raise ValueError(f"Rotation is not enabled for {secret_id}")
+
+    stages = metadata["VersionIdsToStages"].get(token)
+    if stages is None:
+        raise ValueError(f"Version {token} does not belong to {secret_id}")
+    if CURRENT in stages:
+        print(f"Version {token} is already {CURRENT}")
+        return
+    if PENDING not in stages:
+        raise ValueError(f"Version {token} is not {PENDING}")
+
+    STEPS[step](secrets, secret_id, token)
@@ -0,0 +1,212 @@
+"""
+Test suite for the Lambda that rotates the origin verification header.
+"""
+import unittest
+from unittest.mock import patch, MagicMock
+import os
+from botocore.exceptions import ClientError
+
+import handler
+
+ENVIRONMENT = {
+    "HEADER_NAME": "X-Origin-Verify",
+    "LISTENER_RULE_ARN": "arn:aws:elasticloadbalancing:rule/app/test",
+    "REST_API_ID": "api123",
+    "STAGE_NAME": "prod",
+}
+
+
+def header_condition(values):
+    """Return the listener rule condition matching the given header values."""
+    return [{
+        "Field": "http-header",
+        "HttpHeaderConfig": {"HttpHeaderName": "X-Origin-Verify", "Values": values},
+    }]
+
+
+class TestCreateSecret(unittest.TestCase):
+    """Test create_secret function."""
+
+    def test_create_secret_already_exists(self):
+        """Should not create a new value when the pending version exists."""
+        mock_secrets = MagicMock()
+
+        handler.create_secret(mock_secrets, "secret", "token")
+
+        mock_secrets.put_secret_value.assert_not_called()
+
+    def test_create_secret_new_version(self):
+        """Should store a random value as the pending version."""
+        mock_secrets = MagicMock()
+        mock_secrets.get_secret_value.side_effect = ClientError(
+            {"Error": {"Code": "ResourceNotFoundException", "Message": "Not found"}},
+            "GetSecretValue"
+        )
+        mock_secrets.get_random_password.return_value = {"RandomPassword": "new-value"}
+
+        handler.create_secret(mock_secrets, "secret", "token")
+
+        mock_secrets.get_random_password.assert_called_once_with(PasswordLength=48, ExcludePunctuation=True)
+        mock_secrets.put_secret_value.assert_called_once_with(
+            SecretId="secret",
+            ClientRequestToken="token",
+            SecretString="new-value",
+            VersionStages=["AWSPENDING"],
+        )
+
+    def test_create_secret_client_error(self):
+        """Should raise exception when Secrets Manager call fails."""
+        mock_secrets = MagicMock()
+        mock_secrets.get_secret_value.side_effect = ClientError(
+            {"Error": {"Code": "AccessDeniedException", "Message": "Denied!"}},
+            "GetSecretValue"
+        )
+
+        with self.assertRaises(ClientError):
+            handler.create_secret(mock_secrets, "secret", "token")
+
+
+class TestSetSecret(unittest.TestCase):
+    """Test set_secret function."""
+
+    @patch.dict(os.environ, ENVIRONMENT)
+    @patch("boto3.client")
+    def test_set_secret(self, mock_boto_client):
+        """Should accept both values and send the pending value from every integration with the header."""
+        mock_secrets = MagicMock()
+        mock_secrets.get_secret_value.side_effect = [
+            {"SecretString": "current-value"},
+            {"SecretString": "pending-value"},
+        ]
+        mock_elbv2 = MagicMock()
+        mock_apigateway = MagicMock()
+        mock_boto_client.side_effect = lambda service: {"elbv2": mock_elbv2, "apigateway": mock_apigateway}[service]
+        mock_apigateway.get_paginator.return_value.paginate.return_value = [{
+            "items": [
+                {
+                    "id": "res1",
+                    "resourceMethods": {
+                        "POST": {"methodIntegration": {
+                            "requestParameters": {"integration.request.header.X-Origin-Verify": "'current-value'"},
+                        }},
+                        "OPTIONS": {"methodIntegration": {"type": "MOCK"}},
+                    },
+                },
+                {"id": "root"},
+            ],
+        }]
+
+        handler.set_secret(mock_secrets, "secret", "token")
+
+        mock_elbv2.modify_rule.assert_called_once_with(
+            RuleArn="arn:aws:elasticloadbalancing:rule/app/test",
+            Conditions=header_condition(["current-value", "pending-value"]),
+        )
+        mock_apigateway.update_integration.assert_called_once_with(
+            restApiId="api123",
+            resourceId="res1",
+            httpMethod="POST",
+            patchOperations=[{
+                "op": "replace",
+                "path": "/requestParameters/integration.request.header.X-Origin-Verify",
+                "value": "'pending-value'",
+            }],
+        )
+        mock_apigateway.create_deployment.assert_called_once()
+        self.assertEqual(mock_apigateway.create_deployment.call_args.kwargs["stageName"], "prod")
+
+
+class TestFinishSecret(unittest.TestCase):
+    """Test finish_secret function."""
+
+    @patch.dict(os.environ, ENVIRONMENT)
+    @patch("boto3.client")
+    def test_finish_secret(self, mock_boto_client):
+        """Should promote the pending version and only accept its value."""
+        mock_elbv2 = MagicMock()
+        mock_boto_client.return_value = mock_elbv2
+        mock_secrets = MagicMock()
+        mock_secrets.describe_secret.return_value = {
+            "VersionIdsToStages": {"old": ["AWSCURRENT"], "token": ["AWSPENDING"]},
+        }
+        mock_secrets.get_secret_value.return_value = {"SecretString": "pending-value"}
+
+        handler.finish_secret(mock_secrets, "secret", "token")
+
+        mock_secrets.update_secret_version_stage.assert_called_once_with(
+            SecretId="secret",
+            VersionStage="AWSCURRENT",
+            MoveToVersionId="token",
+            RemoveFromVersionId="old",
+        )
+        mock_elbv2.modify_rule.assert_called_once_with(
+            RuleArn="arn:aws:elasticloadbalancing:rule/app/test",
+            Conditions=header_condition(["pending-value"]),
+        )
+
+
+class TestLambdaHandler(unittest.TestCase):
+    """Test lambda_handler function."""
+
+    def event(self, step):
+        """Return a rotation event for the given step."""
+        return {"SecretId": "secret", "ClientRequestToken": "token", "Step": step}
+
+    @patch("boto3.client")
+    def test_lambda_handler_rotation_disabled(self, mock_boto_client):
+        """Should raise when rotation is not enabled for the secret."""
+        mock_secrets = MagicMock()
+        mock_boto_client.return_value = mock_secrets
+        mock_secrets.describe_secret.return_value = {"RotationEnabled": False}
+
+        with self.assertRaises(ValueError):
+            handler.lambda_handler(self.event("createSecret"), None)
+
+    @patch("boto3.client")
+    def test_lambda_handler_unknown_version(self, mock_boto_client):
+        """Should raise when the token is not a version of the secret."""
+        mock_secrets = MagicMock()
+        mock_boto_client.return_value = mock_secrets
+        mock_secrets.describe_secret.return_value = {"RotationEnabled": True, "VersionIdsToStages": {}}
+
+        with self.assertRaises(ValueError):
+            handler.lambda_handler(self.event("createSecret"), None)
+
+    @patch("boto3.client")
+    def test_lambda_handler_already_current(self, mock_boto_client):
+        """Should do nothing when the version is already current."""
+        mock_secrets = MagicMock()
+        mock_boto_client.return_value = mock_secrets
+        mock_secrets.describe_secret.return_value = {
+            "RotationEnabled": True,
+            "VersionIdsToStages": {"token": ["AWSCURRENT"]},
+        }
+
+        handler.lambda_handler(self.event("createSecret"), None)
+
+        mock_secrets.put_secret_value.assert_not_called()
+
+    def test_lambda_handler_unknown_step(self):
+        """Should raise for an unknown rotation step."""
+        with self.assertRaises(ValueError):
+            handler.lambda_handler(self.event("deleteSecret"), None)
+
+    @patch("handler.create_secret")
+    @patch("boto3.client")
+    def test_lambda_handler_runs_step(self, mock_boto_client, mock_create_secret):
+        """Should run the requested step for a pending version."""
+        mock_secrets = MagicMock()
+        mock_boto_client.return_value = mock_secrets
+        mock_secrets.describe_secret.return_value = {
+            "RotationEnabled": True,
+            "VersionIdsToStages": {"token": ["AWSPENDING"]},
+        }
+
+        with patch.dict(handler.STEPS, {"createSecret": mock_create_secret}):
+            handler.lambda_handler(self.event("createSecret"), None)
+
+        mock_create_secret.assert_called_once_with(mock_secrets, "secret", "token")
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -1,5 +1,5 @@
 """
-Lambda to create Postgres table with vector schema and indexes.
+Lambda to create the Postgres database and migrate its vector tables.
 """
 import json
 import os
@@ -7,6 +7,15 @@ import boto3
 import psycopg2
 from botocore.exceptions import ClientError
 
+import migrator
+
+# Index of undeclared tables: HNSW for cosine distance with pgvector's default parameters
+DEFAULT_INDEX = {
+    "index_method": "hnsw",
+    "operator_class": "vector_cosine_ops",
+    "index_options": "m = 16, ef_construction = 64"
+}
+
 
 def get_secret_value(secret_arn):
     """Get database credentials from Secrets Manager."""
@@ -47,12 +56,37 @@ def create_database_if_not_exists(admin_db_config, target_db_name):
         conn.close()
 
 
-def create_table_and_indexes(db_config, table_name):
-    """Create table and indexes if they don't exist."""
-    print(f"Creating table and indexes for: {table_name}")
+def migration_settings(properties):
+    """Read the target version and dry-run mode from the custom resource properties."""
+    target = properties.get("TargetVersion", "latest")
+    return {
+        "target": None if target == "latest" else int(target),
+        "dry_run": properties.get("DryRun", "false") == "true"
+    }
+
+
+def vector_stores(properties):
+    """Read the migration parameters of the declared vector stores from the resource properties."""
+    return json.loads(properties.get("VectorStores", "[]"))
+
+
+def table_parameters(table_name, properties):
+    """Return the migration parameters of a table, using the defaults for undeclared tables."""
+    for store in vector_stores(properties):
+        if store["table"] == table_name:
+            return store
+    return {
+        "table": table_name,
+        "dimensions": os.getenv("EMBEDDING_DIMENSIONS", "1536"),
+        **DEFAULT_INDEX
+    }
+
+
+def migrate_table(db_config, table_name, properties):
+    """Run the migrations of one table in the target database."""
+    print(f"Migrating table: {table_name}")
 
-    # Get embedding dimensions from environment variable
-    embedding_dimensions = os.getenv("EMBEDDING_DIMENSIONS", "1536")
+    parameters = table_parameters(table_name, properties)
 
     conn = psycopg2.connect(
         host=db_config["host"],
@@ -64,61 +98,81 @@ def create_table_and_indexes(db_config, table_name):
     )
 
     try:
-        with conn.cursor() as cursor:
-            # Enable vector extension
-            cursor.execute("CREATE EXTENSION IF NOT EXISTS vector;")
-
-            # Create table if it doesn't exist
-            # Table name is pre-sanitized by the Go code to be a valid SQL identifier
-            create_table_sql = f"""
-                CREATE TABLE IF NOT EXISTS "{table_name}" (
-                    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-                    text TEXT,
-                    embedding vector({embedding_dimensions}),
-                    metadata JSONB
-                )
-            """
-            cursor.execute(create_table_sql)
-            print(f"Table {table_name} created or already exists")
-
-            # Create text search index
-            create_text_index_sql = f"""
-                CREATE INDEX IF NOT EXISTS "{table_name}_text_gin_idx"
-                ON "{table_name}" USING gin (to_tsvector('simple', text))
-            """
-            cursor.execute(create_text_index_sql)
-            print(f"Text search index created for {table_name}")
-
-            # Create vector index - try HNSW first, fallback to IVFFlat
-            try:
-                create_vector_index_sql = f"""
-                    CREATE INDEX IF NOT EXISTS "{table_name}_embedding_hnsw_idx"
-                    ON "{table_name}" USING hnsw (embedding vector_cosine_ops)
-                """
-                cursor.execute(create_vector_index_sql)
-                print(f"HNSW vector index created for {table_name}")
-            except psycopg2.Error as e:
-                if "access method" in str(e) and "hnsw" in str(e):
-                    print(f"HNSW not available, using IVFFlat: {e}")
-                    create_vector_index_sql = f"""
-                        CREATE INDEX IF NOT EXISTS "{table_name}_embedding_ivfflat_idx"
-                        ON "{table_name}" USING ivfflat (embedding vector_cosine_ops)
-                        WITH (lists = 100)
-                    """
-                    cursor.execute(create_vector_index_sql)
-                    print(f"IVFFlat vector index created for {table_name}")
-                else:
-                    print(f"Vector index creation failed: {e}")
-
-        conn.commit()
-        print(f"Successfully created table and indexes for {table_name}")
-
+        result = migrator.migrate(conn, table_name, parameters, **migration_settings(properties))
+        print(f"Table {table_name} is at migration version {result['version']}")
+        return result
     finally:
         conn.close()
 
 
+def migrate(table_names, target_db_name, properties):
+    """Create the target database and migrate each table with the stores and settings in the properties,
+    raising on the first failure."""
+    # Get configuration from environment
+    db_host = os.environ["DB_HOST"]
+    db_port = int(os.environ["DB_PORT"])
+    default_db_name = os.environ["DB_NAME"]  # This is the default cluster database
+    secret_arn = os.environ["DB_SECRET_ARN"]
+
+    # Get database credentials
+    secret_data = get_secret_value(secret_arn)
+
+    # First, create the target database if it doesn't exist
+    admin_db_config = {
+        "host": db_host,
+        "port": db_port,
+        "dbname": default_db_name,  # Connect to default database first
+        "username": secret_data["username"],
+        "password": secret_data["password"]
+    }
+    create_database_if_not_exists(admin_db_config, target_db_name)
+
+    # Then migrate the tables in the target database
+    target_db_config = {
+        "host": db_host,
+        "port": db_port,
+        "dbname": target_db_name,  # Connect to target database
+        "username": secret_data["username"],
+        "password": secret_data["password"]
+    }
+    return [migrate_table(target_db_config, table_name, properties) for table_name in table_names]
+
+
+def handle_deployment(event):
+    """Migrate every declared vector store when CloudFormation creates or updates the schema
+    migration resource.
+
+    Everything the migration depends on is read from the resource properties rather than the
+    function's environment, so that a rollback migrates with the previous properties even though
+    CloudFormation restores the function's configuration only afterwards. Errors propagate so that
+    the custom resource provider fails the deployment and CloudFormation rolls back.
+    """
+    properties = event["ResourceProperties"]
+    physical_id = event.get("PhysicalResourceId") or properties["Database"]
+
+    # Dropping the schema would delete the data, so removing the resource leaves it in place
+    if event["RequestType"] == "Delete":
+        return {"PhysicalResourceId": physical_id}
+
+    print(f"Applying schema version {properties['SchemaVersion']}")
+    tables = [store["table"] for store in vector_stores(properties)]
+    results = migrate(tables, properties["Database"], properties)
+    return {
+        "PhysicalResourceId": physical_id,
+        "Data": {
+            "SchemaVersion": properties["SchemaVersion"],
+            # The version every store has reached
+            "MigrationVersion": str(min((result["version"] for result in results), default=0))
+        }
+    }
+
+
 def lambda_handler(event, _context):
     """Lambda handler function."""
+    # CloudFormation invokes the function through the custom resource provider on deployments
+    if "RequestType" in event:
+        return handle_deployment(event)
+
     try:
         print("Received event:", json.dumps(event, indent=2))
 
@@ -131,41 +185,18 @@ def lambda_handler(event, _context):
         if not target_db_name:
             raise ValueError("Missing 'database' in event")
 
-        # Get configuration from environment
-        db_host = os.environ["DB_HOST"]
-        db_port = int(os.environ["DB_PORT"])
-        default_db_name = os.environ["DB_NAME"]  # This is the default cluster database
-        secret_arn = os.environ["DB_SECRET_ARN"]
-
-        # Get database credentials
-        secret_data = get_secret_value(secret_arn)
-
-        # First, create the target database if it doesn't exist
-        admin_db_config = {
-            "host": db_host,
-            "port": db_port,
-            "dbname": default_db_name,  # Connect to default database first
-            "username": secret_data["username"],
-            "password": secret_data["password"]
-        }
-        create_database_if_not_exists(admin_db_config, target_db_name)
-
-        # Then create table and indexes in the target database
-        target_db_config = {
-            "host": db_host,
-            "port": db_port,
-            "dbname": target_db_name,  # Connect to target database
-            "username": secret_data["username"],
-            "password": secret_data["password"]
-        }
-        create_table_and_indexes(target_db_config, table_name)
+        # Direct invocations migrate to the latest version with the default index
+        result = migrate([table_name], target_db_name, {})[0]
 
         return {
             "status": "success",
-            "message": f"Database {target_db_name} and table {table_name} created successfully"
+            "message": (f"Database {target_db_name} and table {table_name} "
+                        f"migrated to version {result['version']}"),
+            "version": result["version"],
+            "steps": result["steps"]
         }
 
-    except (ValueError, KeyError, psycopg2.Error, ClientError) as e:
+    except (ValueError, KeyError, psycopg2.Error, ClientError, migrator.MigrationError) as e:
         print(f"Error: {e}")
         return {
             "status": "error",
@@ -1,5 +1,5 @@
 """
-Test suite for simplified Lambda handler that creates Postgres table with vector schema.
+Test suite for the Lambda handler that creates the Postgres database and migrates its vector tables.
 """
 import unittest
 from unittest.mock import patch, MagicMock
@@ -10,6 +10,13 @@ import psycopg2
 
 import handler
 
+VECTOR_STORES = [
+    {"table": "documents", "dimensions": "1536", "index_method": "hnsw",
+     "operator_class": "vector_cosine_ops", "index_options": "m = 16, ef_construction = 64"},
+    {"table": "images", "dimensions": "1024", "index_method": "ivfflat",
+     "operator_class": "vector_l2_ops", "index_options": "lists = 100"}
+]
+
 
 class TestGetSecretValue(unittest.TestCase):
     """Test get_secret_value function."""
@@ -115,138 +122,77 @@ class TestCreateDatabaseIfNotExists(unittest.TestCase):
         self.assertTrue(mock_conn.autocommit)
 
 
-class TestCreateTableAndIndexes(unittest.TestCase):
-    """Test create_table_and_indexes function."""
+class TestMigrateTable(unittest.TestCase):
+    """Test migrate_table function."""
+
+    db_config = {
+        "host": "localhost",
+        "port": 5432,
+        "dbname": "testdb",
+        "username": "user",
+        "password": "pass"
+    }
 
-    @patch.dict(os.environ, {"EMBEDDING_DIMENSIONS": "1536"})
+    @patch.dict(os.environ, {"EMBEDDING_DIMENSIONS": "1024"})
+    @patch("handler.migrator.migrate")
     @patch("handler.psycopg2.connect")
-    def test_create_table_and_indexes_success(self, mock_connect):
-        """Should create table and indexes successfully."""
+    def test_migrate_table_uses_property_settings(self, mock_connect, mock_migrate):
+        """Should pass the table parameters, target version and dry-run mode to the migrator."""
         mock_conn = MagicMock()
         mock_connect.return_value = mock_conn
-        mock_cursor = MagicMock()
-        mock_conn.cursor.return_value.__enter__.return_value = mock_cursor
+        mock_migrate.return_value = {"version": 1, "steps": []}
 
-        db_config = {
-            "host": "localhost",
-            "port": 5432,
-            "dbname": "testdb",
-            "username": "user",
-            "password": "pass"
-        }
-        handler.create_table_and_indexes(db_config, "my_table")
+        result = handler.migrate_table(self.db_config, "my_table", {"TargetVersion": "1", "DryRun": "true"})
 
-        # Should execute: extension, table, text index, vector index
-        self.assertEqual(mock_cursor.execute.call_count, 4)
-
-        # Check extension creation
-        first_call = mock_cursor.execute.call_args_list[0][0][0]
-        self.assertIn("CREATE EXTENSION IF NOT EXISTS vector", first_call)
-
-        # Check table creation
-        second_call = mock_cursor.execute.call_args_list[1][0][0]
-        self.assertIn('CREATE TABLE IF NOT EXISTS "my_table"', second_call)
-        self.assertIn("id UUID PRIMARY KEY", second_call)
-        self.assertIn("vector(1536)", second_call)
-        self.assertIn("metadata JSONB", second_call)
-
-        # Check text index creation
-        third_call = mock_cursor.execute.call_args_list[2][0][0]
-        self.assertIn("CREATE INDEX IF NOT EXISTS", third_call)
-        self.assertIn("gin", third_call)
-        self.assertIn("to_tsvector", third_call)
-
-        # Check vector index creation (HNSW)
-        fourth_call = mock_cursor.execute.call_args_list[3][0][0]
-        self.assertIn("CREATE INDEX IF NOT EXISTS", fourth_call)
-        self.assertIn("hnsw", fourth_call)
-        self.assertIn("vector_cosine_ops", fourth_call)
-
-        mock_conn.commit.assert_called_once()
+        self.assertEqual(result["version"], 1)
+        mock_migrate.assert_called_once_with(
+            mock_conn, "my_table",
+            {"table": "my_table", "dimensions": "1024", **handler.DEFAULT_INDEX},
+            target=1, dry_run=True
+        )
         mock_conn.close.assert_called_once()
 
-    @patch.dict(os.environ, {"EMBEDDING_DIMENSIONS": "1024"})
+    @patch.dict(os.environ, {}, clear=True)
+    @patch("handler.migrator.migrate")
     @patch("handler.psycopg2.connect")
-    def test_create_table_custom_dimensions(self, mock_connect):
-        """Should use custom embedding dimensions from environment."""
+    def test_migrate_table_defaults_to_latest(self, mock_connect, mock_migrate):
+        """Should migrate to the latest version with the default dimensions."""
         mock_conn = MagicMock()
         mock_connect.return_value = mock_conn
-        mock_cursor = MagicMock()
-        mock_conn.cursor.return_value.__enter__.return_value = mock_cursor
+        mock_migrate.return_value = {"version": 2, "steps": []}
 
-        db_config = {
-            "host": "localhost",
-            "port": 5432,
-            "dbname": "testdb",
-            "username": "user",
-            "password": "pass"
-        }
-        handler.create_table_and_indexes(db_config, "my_table")
+        handler.migrate_table(self.db_config, "my_table", {})
 
-        # Check that custom dimensions are used
-        second_call = mock_cursor.execute.call_args_list[1][0][0]
-        self.assertIn("vector(1024)", second_call)
+        mock_migrate.assert_called_once_with(
+            mock_conn, "my_table",
+            {"table": "my_table", "dimensions": "1536", **handler.DEFAULT_INDEX},
+            target=None, dry_run=False
+        )
 
+    @patch.dict(os.environ, {}, clear=True)
+    @patch("handler.migrator.migrate")
     @patch("handler.psycopg2.connect")
-    def test_create_table_hnsw_fallback_to_ivfflat(self, mock_connect):
-        """Should fallback to IVFFlat when HNSW is not available."""
+    def test_migrate_table_uses_vector_store_parameters(self, mock_connect, mock_migrate):
+        """Should migrate a declared vector store with its dimensions and index."""
         mock_conn = MagicMock()
         mock_connect.return_value = mock_conn
-        mock_cursor = MagicMock()
-        mock_conn.cursor.return_value.__enter__.return_value = mock_cursor
+        mock_migrate.return_value = {"version": 3, "steps": []}
 
-        # Mock HNSW failure
-        def mock_execute_side_effect(sql):
-            if "hnsw" in sql:
-                raise psycopg2.Error("access method hnsw does not exist")
+        handler.migrate_table(self.db_config, "images", {"VectorStores": json.dumps(VECTOR_STORES)})
 
-        mock_cursor.execute.side_effect = mock_execute_side_effect
-
-        db_config = {
-            "host": "localhost",
-            "port": 5432,
-            "dbname": "testdb",
-            "username": "user",
-            "password": "pass"
-        }
-        handler.create_table_and_indexes(db_config, "my_table")
-
-        # Should execute: extension, table, text index, failed HNSW, successful IVFFlat
-        self.assertEqual(mock_cursor.execute.call_count, 5)
-
-        # Check that IVFFlat index was created
-        fifth_call = mock_cursor.execute.call_args_list[4][0][0]
-        self.assertIn("CREATE INDEX IF NOT EXISTS", fifth_call)
-        self.assertIn("ivfflat", fifth_call)
-        self.assertIn("vector_cosine_ops", fifth_call)
-        self.assertIn("lists = 100", fifth_call)
+        mock_migrate.assert_called_once_with(
+            mock_conn, "images", VECTOR_STORES[1], target=None, dry_run=False
+        )
 
+    @patch("handler.migrator.migrate", side_effect=psycopg2.Error("Migration failed"))
     @patch("handler.psycopg2.connect")
-    def test_create_table_vector_index_failure(self, mock_connect):
-        """Should handle vector index creation failure gracefully."""
+    def test_migrate_table_closes_connection_on_failure(self, mock_connect, _mock_migrate):
+        """Should close the connection and raise when a migration fails."""
         mock_conn = MagicMock()
         mock_connect.return_value = mock_conn
-        mock_cursor = MagicMock()
-        mock_conn.cursor.return_value.__enter__.return_value = mock_cursor
-
-        # Mock vector index failure with unexpected error
-        def mock_execute_side_effect(sql):
-            if "hnsw" in sql or "ivfflat" in sql:
-                raise psycopg2.Error("unexpected vector index error")
-
-        mock_cursor.execute.side_effect = mock_execute_side_effect
-
-        # Should not raise exception
-        db_config = {
-            "host": "localhost",
-            "port": 5432,
-            "dbname": "testdb",
-            "username": "user",
-            "password": "pass"
-        }
-        handler.create_table_and_indexes(db_config, "my_table")
 
-        mock_conn.commit.assert_called_once()
+        with self.assertRaises(psycopg2.Error):
+            handler.migrate_table(self.db_config, "my_table", {})
         mock_conn.close.assert_called_once()
 
 
@@ -261,16 +207,19 @@ class TestLambdaHandler(unittest.TestCase):
     })
     @patch("handler.get_secret_value")
     @patch("handler.create_database_if_not_exists")
-    @patch("handler.create_table_and_indexes")
-    def test_lambda_handler_success(self, mock_create_table, mock_create_db, mock_get_secret):
+    @patch("handler.migrate_table")
+    def test_lambda_handler_success(self, mock_migrate_table, mock_create_db, mock_get_secret):
         """Should return success when all operations complete successfully."""
         mock_get_secret.return_value = {"username": "user", "password": "pass"}
+        mock_migrate_table.return_value = {"version": 2, "steps": ["up 0002_add_embedding_index"]}
 
         event = {"table": "my_table", "database": "my_database"}
         result = handler.lambda_handler(event, {})
 
         self.assertEqual(result["status"], "success")
-        self.assertIn("Database my_database and table my_table created successfully", result["message"])
+        self.assertIn("Database my_database and table my_table migrated to version 2",
+                      result["message"])
+        self.assertEqual(result["version"], 2)
 
         # Verify functions were called
         mock_get_secret.assert_called_once_with("arn:secret")
@@ -287,8 +236,8 @@ class TestLambdaHandler(unittest.TestCase):
             "my_database"
         )
 
-        # Verify create_table_and_indexes was called with target database config
-        mock_create_table.assert_called_once_with(
+        # Verify migrate_table was called with target database config
+        mock_migrate_table.assert_called_once_with(
             {
                 "host": "localhost",
                 "port": 5432,
@@ -296,7 +245,8 @@ class TestLambdaHandler(unittest.TestCase):
                 "username": "user",
                 "password": "pass"
             },
-            "my_table"
+            "my_table",
+            {}
         )
 
     def test_lambda_handler_missing_table(self):
@@ -377,7 +327,7 @@ class TestLambdaHandler(unittest.TestCase):
     })
     @patch("handler.get_secret_value")
     @patch("handler.create_database_if_not_exists")
-    @patch("handler.create_table_and_indexes", side_effect=psycopg2.Error("Table creation failed"))
+    @patch("handler.migrate_table", side_effect=psycopg2.Error("Table creation failed"))
     def test_lambda_handler_table_creation_error(self, _mock_create_table, _mock_create_db, mock_get_secret):
         """Should return error when table creation fails."""
         mock_get_secret.return_value = {"username": "user", "password": "pass"}
@@ -388,5 +338,95 @@ class TestLambdaHandler(unittest.TestCase):
         self.assertIn("Table creation failed", result["message"])
 
 
+
+class TestHandleDeployment(unittest.TestCase):
+    """Test the CloudFormation custom resource path of lambda_handler."""
+
+    properties = {
+        "ServiceToken": "arn:provider",
+        "SchemaVersion": "v2",
+        "Database": "my_database",
+        "VectorStores": json.dumps(VECTOR_STORES)
+    }
+
+    @patch("handler.migrate")
+    def test_create_migrates_every_store_and_reports_version(self, mock_migrate):
+        """Should migrate every declared store and return the version they all reached on Create."""
+        mock_migrate.return_value = [{"version": 3, "steps": []}, {"version": 2, "steps": []}]
+        event = {"RequestType": "Create", "ResourceProperties": self.properties}
+        result = handler.lambda_handler(event, {})
+
+        mock_migrate.assert_called_once_with(["documents", "images"], "my_database", self.properties)
+        self.assertEqual(result["PhysicalResourceId"], "my_database")
+        self.assertEqual(result["Data"], {"SchemaVersion": "v2", "MigrationVersion": "2"})
+
+    @patch("handler.migrate")
+    def test_update_keeps_physical_id(self, mock_migrate):
+        """Should migrate on Update without replacing the resource."""
+        event = {
+            "RequestType": "Update",
+            "PhysicalResourceId": "existing-id",
+            "ResourceProperties": self.properties
+        }
+        result = handler.lambda_handler(event, {})
+
+        mock_migrate.assert_called_once_with(["documents", "images"], "my_database", self.properties)
+        self.assertEqual(result["PhysicalResourceId"], "existing-id")
+
+    @patch.dict(os.environ, {
+        "DB_HOST": "localhost",
+        "DB_PORT": "5432",
+        "DB_NAME": "testdb",
+        "DB_SECRET_ARN": "arn:secret",
+        # Left over from the failed deployment, which the rollback must not pick up
+        "VECTOR_STORES": json.dumps(VECTOR_STORES[:1]),
+        "MIGRATION_TARGET_VERSION": "2"
+    })
+    @patch("handler.migrator.migrate")
+    @patch("handler.psycopg2.connect")
+    @patch("handler.create_database_if_not_exists")
+    @patch("handler.get_secret_value")
+    def test_rollback_migrates_with_previous_properties(self, mock_get_secret, _mock_create_db, mock_connect,
+                                                        mock_migrate):
+        """Should migrate with the event's properties, not settings left in the function's environment."""
+        mock_get_secret.return_value = {"username": "user", "password": "pass"}
+        mock_migrate.return_value = {"version": 1, "steps": []}
+        previous = {**self.properties, "TargetVersion": "1"}
+        event = {
+            "RequestType": "Update",
+            "PhysicalResourceId": "existing-id",
+            "ResourceProperties": previous
+        }
+        handler.lambda_handler(event, {})
+
+        conn = mock_connect.return_value
+        mock_migrate.assert_any_call(conn, "documents", VECTOR_STORES[0], target=1, dry_run=False)
+        mock_migrate.assert_any_call(conn, "images", VECTOR_STORES[1], target=1, dry_run=False)
+
+    @patch("handler.migrate")
+    def test_delete_leaves_schema_in_place(self, mock_migrate):
+        """Should not touch the database on Delete."""
+        event = {
+            "RequestType": "Delete",
+            "PhysicalResourceId": "existing-id",
+            "ResourceProperties": self.properties
+        }
+        result = handler.lambda_handler(event, {})
+
+        mock_migrate.assert_not_called()
+        self.assertEqual(result, {"PhysicalResourceId": "existing-id"})
+
+    @patch("handler.migrate", side_effect=psycopg2.Error("Migration failed"))
+    def test_failure_fails_deployment(self, _mock_migrate):
+        """Should raise so that the deployment fails and rolls back."""
+        event = {
+            "RequestType": "Update",
+            "PhysicalResourceId": "existing-id",
+            "ResourceProperties": self.properties
+        }
+        with self.assertRaises(psycopg2.Error):
+            handler.lambda_handler(event, {})
+
+
 if __name__ == '__main__':
     unittest.main()
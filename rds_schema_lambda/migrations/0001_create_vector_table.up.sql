@@ -0,0 +1,12 @@
+-- Vector store table with a full-text index on the chunk text
+CREATE EXTENSION IF NOT EXISTS vector;
+
+CREATE TABLE IF NOT EXISTS "${table}" (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    text TEXT,
+    embedding vector(${dimensions}),
+    metadata JSONB
+);
+
+CREATE INDEX IF NOT EXISTS "${table}_text_gin_idx"
+    ON "${table}" USING gin (to_tsvector('simple', text));
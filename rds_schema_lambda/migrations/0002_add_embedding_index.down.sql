@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "${table}_embedding_hnsw_idx";
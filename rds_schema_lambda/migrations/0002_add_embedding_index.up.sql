@@ -0,0 +1,3 @@
+-- Approximate nearest neighbour index for cosine similarity search
+CREATE INDEX IF NOT EXISTS "${table}_embedding_hnsw_idx"
+    ON "${table}" USING hnsw (embedding vector_cosine_ops);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "${table}_embedding_idx";
+CREATE INDEX IF NOT EXISTS "${table}_embedding_hnsw_idx"
+    ON "${table}" USING hnsw (embedding vector_cosine_ops);
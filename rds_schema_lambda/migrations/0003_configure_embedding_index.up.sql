@@ -0,0 +1,5 @@
+-- Rebuild the embedding index with the vector store's index type and distance metric
+DROP INDEX IF EXISTS "${table}_embedding_hnsw_idx";
+DROP INDEX IF EXISTS "${table}_embedding_ivfflat_idx";
+CREATE INDEX IF NOT EXISTS "${table}_embedding_idx"
+    ON "${table}" USING ${index_method} (embedding ${operator_class}) WITH (${index_options});
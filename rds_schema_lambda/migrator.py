@@ -0,0 +1,199 @@
+"""
+Ordered, versioned schema migrations for the vector store tables.
+
+Migrations live in the migrations directory as NNNN_name.up.sql with an optional
+NNNN_name.down.sql. They are templates: ${table} and ${dimensions} are replaced for the
+table being migrated, and a literal dollar sign is written $$. Each table records its
+applied migrations in schema_migrations with the checksum of the up file, so a migration
+edited after it was applied is reported instead of silently diverging.
+"""
+import hashlib
+import os
+import re
+from dataclasses import dataclass
+from string import Template
+from typing import Optional
+
+MIGRATIONS_DIR = os.path.join(os.path.dirname(os.path.abspath(__file__)), "migrations")
+
+MIGRATION_FILE_PATTERN = re.compile(r"^(\d{4})_([a-z0-9_]+)\.(up|down)\.sql$")
+
+# Advisory lock key serializing migrations of a database across concurrent invocations
+LOCK_KEY = "schema_migrations"
+
+BOOKKEEPING_TABLE_SQL = """
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+        table_name TEXT NOT NULL,
+        version INTEGER NOT NULL,
+        name TEXT NOT NULL,
+        checksum TEXT NOT NULL,
+        applied_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+        PRIMARY KEY (table_name, version)
+    )
+"""
+
+
+class MigrationError(Exception):
+    """Raised when the migrations cannot be planned."""
+
+
+@dataclass(frozen=True)
+class Migration:
+    """A numbered migration with its up and optional down SQL templates."""
+    version: int
+    name: str
+    up_sql: str
+    down_sql: Optional[str] = None
+
+    @property
+    def checksum(self):
+        """SHA-256 of the up template, recorded when the migration is applied."""
+        return hashlib.sha256(self.up_sql.encode("utf-8")).hexdigest()
+
+    @property
+    def label(self):
+        """File name stem of the migration, such as 0001_create_vector_table."""
+        return f"{self.version:04d}_{self.name}"
+
+
+@dataclass(frozen=True)
+class Step:
+    """A migration applied in one direction."""
+    direction: str
+    migration: Migration
+
+    def sql(self, parameters):
+        """Render the SQL of this direction for the table parameters."""
+        template = self.migration.up_sql if self.direction == "up" else self.migration.down_sql
+        return Template(template).substitute(parameters)
+
+
+def load_migrations(directory=MIGRATIONS_DIR):
+    """Load the migrations in version order."""
+    found = {}
+    for file_name in sorted(os.listdir(directory)):
+        match = MIGRATION_FILE_PATTERN.match(file_name)
+        if not match:
+            continue
+        version, name, direction = int(match.group(1)), match.group(2), match.group(3)
+        entry = found.setdefault(version, {"name": name})
+        if entry["name"] != name:
+            raise MigrationError(
+                f"Migration {version} has files named {entry['name']} and {name}")
+        with open(os.path.join(directory, file_name), encoding="utf-8") as sql_file:
+            entry[direction] = sql_file.read()
+
+    migrations = []
+    for version in sorted(found):
+        entry = found[version]
+        if "up" not in entry:
+            raise MigrationError(f"Migration {version} ({entry['name']}) has no up file")
+        migrations.append(Migration(version, entry["name"], entry["up"], entry.get("down")))
+    return migrations
+
+
+def verify_checksums(migrations, applied):
+    """Check every applied migration is still bundled and unchanged.
+
+    applied maps each applied version to the checksum recorded for it.
+    """
+    by_version = {migration.version: migration for migration in migrations}
+    for version, checksum in sorted(applied.items()):
+        migration = by_version.get(version)
+        if migration is None:
+            raise MigrationError(f"Applied migration {version} is not bundled")
+        if migration.checksum != checksum:
+            raise MigrationError(f"Migration {migration.label} changed after it was applied")
+
+
+def plan(migrations, applied, target=None):
+    """Return the steps taking a table from its applied migrations to the target version.
+
+    A target of None means the latest version. A target below the applied versions rolls
+    them back, newest first, with their down migrations.
+    """
+    verify_checksums(migrations, applied)
+
+    versions = [migration.version for migration in migrations]
+    if target is None:
+        target = max(versions, default=0)
+    if target != 0 and target not in versions:
+        raise MigrationError(f"Unknown target version {target}")
+
+    current = max(applied, default=0)
+    pending = [m for m in migrations if m.version <= target and m.version not in applied]
+    out_of_order = [m.label for m in pending if m.version < current]
+    if out_of_order:
+        raise MigrationError(
+            f"Migrations {', '.join(out_of_order)} are older than applied version {current}")
+    if pending:
+        return [Step("up", migration) for migration in pending]
+
+    rollback = [m for m in reversed(migrations) if m.version in applied and m.version > target]
+    irreversible = [m.label for m in rollback if m.down_sql is None]
+    if irreversible:
+        raise MigrationError(f"Migrations {', '.join(irreversible)} have no down file")
+    return [Step("down", migration) for migration in rollback]
+
+
+def record(cursor, table, step):
+    """Record an applied step in schema_migrations."""
+    migration = step.migration
+    if step.direction == "up":
+        cursor.execute(
+            "INSERT INTO schema_migrations (table_name, version, name, checksum) "
+            "VALUES (%s, %s, %s, %s)",
+            (table, migration.version, migration.name, migration.checksum))
+    else:
+        cursor.execute(
+            "DELETE FROM schema_migrations WHERE table_name = %s AND version = %s",
+            (table, migration.version))
+
+
+def migrate(conn, table, parameters, target=None, dry_run=False, migrations=None):
+    """Migrate one table to the target version while holding the database's advisory lock.
+
+    Each step runs in its own transaction together with its bookkeeping, so a failure leaves
+    the table at the last completed version. A dry run logs the steps without applying them.
+    Returns the version the table is at afterwards, or would be at for a dry run, and the
+    labels of the steps.
+    """
+    if migrations is None:
+        migrations = load_migrations()
+
+    with conn.cursor() as cursor:
+        cursor.execute("SELECT pg_advisory_lock(hashtext(%s))", (LOCK_KEY,))
+    try:
+        with conn.cursor() as cursor:
+            cursor.execute(BOOKKEEPING_TABLE_SQL)
+            cursor.execute(
+                "SELECT version, checksum FROM schema_migrations WHERE table_name = %s",
+                (table,))
+            applied = dict(cursor.fetchall())
+        conn.commit()
+
+        versions = set(applied)
+        steps = []
+        for step in plan(migrations, applied, target):
+            label = f"{step.direction} {step.migration.label}"
+            steps.append(label)
+            if dry_run:
+                print(f"Dry run, would apply {label} to {table}:\n{step.sql(parameters)}")
+            else:
+                print(f"Applying {label} to {table}")
+                with conn.cursor() as cursor:
+                    cursor.execute(step.sql(parameters))
+                    record(cursor, table, step)
+                conn.commit()
+            if step.direction == "up":
+                versions.add(step.migration.version)
+            else:
+                versions.discard(step.migration.version)
+
+        return {"version": max(versions, default=0), "steps": steps}
+    finally:
+        # Discard a failed step before releasing the lock for the next invocation
+        conn.rollback()
+        with conn.cursor() as cursor:
+            cursor.execute("SELECT pg_advisory_unlock(hashtext(%s))", (LOCK_KEY,))
+        conn.commit()
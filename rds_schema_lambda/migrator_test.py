@@ -0,0 +1,205 @@
+"""
+Test suite for the versioned schema migrations.
+"""
+import os
+import tempfile
+import unittest
+from unittest.mock import MagicMock
+
+import psycopg2
+
+import migrator
+
+FIRST = migrator.Migration(1, "create_table", 'CREATE TABLE "${table}" ();',
+                           'DROP TABLE "${table}";')
+SECOND = migrator.Migration(2, "add_index", 'CREATE INDEX "${table}_idx";')
+THIRD = migrator.Migration(3, "add_column", 'ALTER TABLE "${table}" ADD c INT;',
+                           'ALTER TABLE "${table}" DROP c;')
+
+
+def fake_connection(applied=None, fail_on=None):
+    """Return a connection whose cursor reports the applied rows and records every statement."""
+    conn = MagicMock()
+    cursor = MagicMock()
+    conn.cursor.return_value.__enter__.return_value = cursor
+    cursor.fetchall.return_value = list((applied or {}).items())
+
+    def execute(sql, _params=None):
+        if fail_on and fail_on in sql:
+            raise psycopg2.Error(f"failed: {fail_on}")
+
+    cursor.execute.side_effect = execute
+    return conn, cursor
+
+
+def statements(cursor):
+    """Return the SQL of every executed statement."""
+    return [call.args[0] for call in cursor.execute.call_args_list]
+
+
+class TestLoadMigrations(unittest.TestCase):
+    """Test load_migrations function."""
+
+    def test_loads_bundled_migrations_in_order(self):
+        """Should load the bundled migrations with their down files."""
+        migrations = migrator.load_migrations()
+
+        self.assertEqual([m.label for m in migrations],
+                         ["0001_create_vector_table", "0002_add_embedding_index",
+                          "0003_configure_embedding_index"])
+        self.assertTrue(all(m.down_sql for m in migrations))
+
+    def test_bundled_migrations_create_the_vector_table(self):
+        """Should render the vector table, text index and the store's embedding index."""
+        parameters = {"table": "my_table", "dimensions": "1024", "index_method": "ivfflat",
+                      "operator_class": "vector_ip_ops", "index_options": "lists = 100"}
+        sql = "\n".join(migrator.Step("up", m).sql(parameters)
+                        for m in migrator.load_migrations())
+
+        self.assertIn("CREATE EXTENSION IF NOT EXISTS vector", sql)
+        self.assertIn('CREATE TABLE IF NOT EXISTS "my_table"', sql)
+        self.assertIn("vector(1024)", sql)
+        self.assertIn("to_tsvector", sql)
+        self.assertIn('DROP INDEX IF EXISTS "my_table_embedding_hnsw_idx"', sql)
+        self.assertIn("USING ivfflat (embedding vector_ip_ops) WITH (lists = 100)", sql)
+
+    def test_rejects_migration_without_up_file(self):
+        """Should fail when a version only has a down file."""
+        with tempfile.TemporaryDirectory() as directory:
+            with open(os.path.join(directory, "0001_only_down.down.sql"), "w",
+                      encoding="utf-8") as sql_file:
+                sql_file.write("SELECT 1;")
+
+            with self.assertRaises(migrator.MigrationError):
+                migrator.load_migrations(directory)
+
+    def test_rejects_mismatched_names(self):
+        """Should fail when the up and down files of a version have different names."""
+        with tempfile.TemporaryDirectory() as directory:
+            for file_name in ("0001_first.up.sql", "0001_second.down.sql"):
+                with open(os.path.join(directory, file_name), "w", encoding="utf-8") as sql_file:
+                    sql_file.write("SELECT 1;")
+
+            with self.assertRaises(migrator.MigrationError):
+                migrator.load_migrations(directory)
+
+
+class TestPlan(unittest.TestCase):
+    """Test plan function."""
+
+    migrations = [FIRST, SECOND, THIRD]
+
+    def test_applies_everything_to_a_new_table(self):
+        """Should apply every migration in order when nothing is applied."""
+        steps = migrator.plan(self.migrations, {})
+        self.assertEqual([(s.direction, s.migration.version) for s in steps],
+                         [("up", 1), ("up", 2), ("up", 3)])
+
+    def test_stops_at_target_version(self):
+        """Should only apply migrations up to the target."""
+        steps = migrator.plan(self.migrations, {1: FIRST.checksum}, target=2)
+        self.assertEqual([(s.direction, s.migration.version) for s in steps], [("up", 2)])
+
+    def test_rolls_back_newest_first(self):
+        """Should run the down migrations above the target in reverse order."""
+        applied = {1: FIRST.checksum, 3: THIRD.checksum}
+        steps = migrator.plan([FIRST, THIRD], applied, target=0)
+        self.assertEqual([(s.direction, s.migration.version) for s in steps],
+                         [("down", 3), ("down", 1)])
+
+    def test_nothing_to_do_at_target(self):
+        """Should return no steps when the table is at the target version."""
+        applied = {m.version: m.checksum for m in self.migrations}
+        self.assertEqual(migrator.plan(self.migrations, applied), [])
+
+    def test_rejects_changed_migration(self):
+        """Should fail when an applied migration's checksum no longer matches."""
+        with self.assertRaisesRegex(migrator.MigrationError, "changed after it was applied"):
+            migrator.plan(self.migrations, {1: "edited"})
+
+    def test_rejects_missing_migration(self):
+        """Should fail when an applied migration is no longer bundled."""
+        with self.assertRaisesRegex(migrator.MigrationError, "not bundled"):
+            migrator.plan([FIRST], {1: FIRST.checksum, 2: SECOND.checksum})
+
+    def test_rejects_unknown_target(self):
+        """Should fail for a target version that does not exist."""
+        with self.assertRaisesRegex(migrator.MigrationError, "Unknown target version 9"):
+            migrator.plan(self.migrations, {}, target=9)
+
+    def test_rejects_out_of_order_migration(self):
+        """Should fail when a new migration is numbered below an applied one."""
+        applied = {1: FIRST.checksum, 3: THIRD.checksum}
+        with self.assertRaisesRegex(migrator.MigrationError, "older than applied version 3"):
+            migrator.plan(self.migrations, applied)
+
+    def test_rejects_rollback_without_down_file(self):
+        """Should fail before rolling back a migration without a down file."""
+        applied = {m.version: m.checksum for m in self.migrations}
+        with self.assertRaisesRegex(migrator.MigrationError, "0002_add_index have no down file"):
+            migrator.plan(self.migrations, applied, target=1)
+
+
+class TestMigrate(unittest.TestCase):
+    """Test migrate function."""
+
+    parameters = {"table": "my_table", "dimensions": "1536"}
+
+    def test_applies_and_records_each_step_under_the_lock(self):
+        """Should lock, apply and record each step in its own transaction, then unlock."""
+        conn, cursor = fake_connection()
+
+        result = migrator.migrate(conn, "my_table", self.parameters, migrations=[FIRST, SECOND])
+
+        self.assertEqual(result, {"version": 2, "steps": ["up 0001_create_table",
+                                                          "up 0002_add_index"]})
+        executed = statements(cursor)
+        self.assertIn("pg_advisory_lock", executed[0])
+        self.assertIn('CREATE TABLE "my_table" ();', executed)
+        self.assertIn('CREATE INDEX "my_table_idx";', executed)
+        self.assertIn("pg_advisory_unlock", executed[-1])
+        inserts = [c.args[1] for c in cursor.execute.call_args_list
+                   if c.args[0].startswith("INSERT INTO schema_migrations")]
+        self.assertEqual(inserts, [("my_table", 1, "create_table", FIRST.checksum),
+                                   ("my_table", 2, "add_index", SECOND.checksum)])
+        # Bookkeeping table, both steps and the unlock
+        self.assertEqual(conn.commit.call_count, 4)
+
+    def test_rolls_back_to_target(self):
+        """Should run the down migration and delete its bookkeeping row."""
+        conn, cursor = fake_connection({1: FIRST.checksum, 3: THIRD.checksum})
+
+        result = migrator.migrate(conn, "my_table", self.parameters, target=1,
+                                  migrations=[FIRST, THIRD])
+
+        self.assertEqual(result["version"], 1)
+        self.assertIn('ALTER TABLE "my_table" DROP c;', statements(cursor))
+        self.assertTrue(any(sql.startswith("DELETE FROM schema_migrations")
+                            for sql in statements(cursor)))
+
+    def test_dry_run_applies_nothing(self):
+        """Should report the steps without running them."""
+        conn, cursor = fake_connection()
+
+        result = migrator.migrate(conn, "my_table", self.parameters, dry_run=True,
+                                  migrations=[FIRST, SECOND])
+
+        self.assertEqual(result["version"], 2)
+        self.assertEqual(len(result["steps"]), 2)
+        executed = statements(cursor)
+        self.assertNotIn('CREATE TABLE "my_table" ();', executed)
+        self.assertFalse(any(sql.startswith("INSERT") for sql in executed))
+
+    def test_failure_rolls_back_and_releases_the_lock(self):
+        """Should roll back the failed step, release the lock and raise."""
+        conn, cursor = fake_connection(fail_on="CREATE INDEX")
+
+        with self.assertRaises(psycopg2.Error):
+            migrator.migrate(conn, "my_table", self.parameters, migrations=[FIRST, SECOND])
+
+        conn.rollback.assert_called_once()
+        self.assertIn("pg_advisory_unlock", statements(cursor)[-1])
+
+
+if __name__ == '__main__':
+    unittest.main()
@@ -0,0 +1,52 @@
+package stack
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// AccessLogBucketProps defines the properties for the AccessLogBucket construct.
+type AccessLogBucketProps struct {
+	// Environment supplies the removal policy. Defaults to DevEnvironment.
+	Environment *EnvironmentConfig
+}
+
+// AccessLogBucket receives S3 server access logs, load balancer access logs and CloudFront standard logs.
+// Its name is generated by CloudFormation.
+type AccessLogBucket struct {
+	constructs.Construct
+
+	// Bucket is the log destination. ACLs stay enabled because CloudFront delivers logs through them.
+	Bucket awss3.IBucket
+}
+
+// NewAccessLogBucket creates the access log bucket.
+func NewAccessLogBucket(scope constructs.Construct, id string, props *AccessLogBucketProps) *AccessLogBucket {
+	this := constructs.NewConstruct(scope, &id)
+	environment := environmentOrDefault(props.Environment)
+
+	bucket := awss3.NewBucket(this, jsii.String("Bucket"), &awss3.BucketProps{
+		// Load balancers can only deliver logs to buckets encrypted with S3 managed keys
+		Encryption:        awss3.BucketEncryption_S3_MANAGED,
+		BlockPublicAccess: awss3.BlockPublicAccess_BLOCK_ALL(),
+		ObjectOwnership:   awss3.ObjectOwnership_BUCKET_OWNER_PREFERRED,
+		EnforceSSL:        jsii.Bool(true),
+		RemovalPolicy:     environment.statefulRemovalPolicy(),
+		AutoDeleteObjects: jsii.Bool(environment.autoDeleteOnRemoval()),
+	})
+	SuppressSecurityRule(bucket, SecurityRuleMissingAccessLogs, "This is the access log destination; logging it to itself would loop.")
+
+	return &AccessLogBucket{
+		Construct: this,
+		Bucket:    bucket,
+	}
+}
+
+// accessLogPrefix returns prefix when logs are delivered to destination, and nil when access logging is off.
+func accessLogPrefix(destination awss3.IBucket, prefix string) *string {
+	if destination == nil {
+		return nil
+	}
+	return jsii.String(prefix)
+}
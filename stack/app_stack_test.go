@@ -30,21 +30,21 @@ func TestAppStack_CreatesExpectedResources(t *testing.T) {
 			})
 		})
 
-		t.Run("creates public subnets only", func(_ *testing.T) {
-			template.ResourceCountIs(jsii.String("AWS::EC2::Subnet"), jsii.Number(2))
+		t.Run("creates public and isolated database subnets", func(_ *testing.T) {
+			template.ResourceCountIs(jsii.String("AWS::EC2::Subnet"), jsii.Number(4))
 		})
 
 		t.Run("creates appropriate security groups", func(_ *testing.T) {
-			// Should have: RDS default SG, Lambda migration SG, VPC default SG, ECS service SG
-			template.ResourceCountIs(jsii.String("AWS::EC2::SecurityGroup"), jsii.Number(4))
+			// Should have: RDS default SG, Lambda migration SG, VPC default SG, ECS service SG, Secrets Manager endpoint SG
+			template.ResourceCountIs(jsii.String("AWS::EC2::SecurityGroup"), jsii.Number(5))
 		})
 	})
 
 	// Test storage infrastructure
 	t.Run("Storage", func(t *testing.T) {
 		t.Run("creates S3 bucket with security configurations", func(_ *testing.T) {
-			// We now have 2 S3 buckets: one for backend storage and one for frontend
-			template.ResourceCountIs(jsii.String("AWS::S3::Bucket"), jsii.Number(2))
+			// We now have 3 S3 buckets: backend storage, frontend and access logs
+			template.ResourceCountIs(jsii.String("AWS::S3::Bucket"), jsii.Number(3))
 			template.HasResourceProperties(jsii.String("AWS::S3::Bucket"), map[string]interface{}{
 				"VersioningConfiguration": map[string]interface{}{
 					"Status": "Enabled",
@@ -77,8 +77,8 @@ func TestAppStack_CreatesExpectedResources(t *testing.T) {
 		})
 
 		t.Run("creates Secrets Manager secret for DB credentials", func(_ *testing.T) {
-			// We now have 3 secrets: RDS credentials, backend secrets, frontend secrets
-			template.ResourceCountIs(jsii.String("AWS::SecretsManager::Secret"), jsii.Number(3))
+			// We now have 5 secrets: RDS credentials, Git token, backend secrets, frontend secrets, origin header
+			template.ResourceCountIs(jsii.String("AWS::SecretsManager::Secret"), jsii.Number(5))
 
 			// Test the RDS credentials secret specifically
 			template.HasResourceProperties(jsii.String("AWS::SecretsManager::Secret"), map[string]interface{}{
@@ -101,7 +101,7 @@ func TestAppStack_CreatesExpectedResources(t *testing.T) {
 			template.HasResourceProperties(jsii.String("AWS::Lambda::Function"), map[string]interface{}{
 				"Handler": "handler.lambda_handler",
 				"Runtime": "python3.12",
-				"Timeout": 10,
+				"Timeout": 600,
 			})
 		})
 	})
@@ -125,12 +125,13 @@ func TestAppStack_CreatesExpectedResources(t *testing.T) {
 		t.Run("creates ECR repository", func(_ *testing.T) {
 			template.ResourceCountIs(jsii.String("AWS::ECR::Repository"), jsii.Number(1))
 			template.HasResourceProperties(jsii.String("AWS::ECR::Repository"), map[string]interface{}{
-				"RepositoryName": "refactor-ecr-repo",
+				"RepositoryName": "code-refactor-ecr-repo",
 			})
 		})
 
 		t.Run("creates CloudWatch log group", func(_ *testing.T) {
-			template.ResourceCountIs(jsii.String("AWS::Logs::LogGroup"), jsii.Number(1))
+			// One for the containers, one for the API access logs
+			template.ResourceCountIs(jsii.String("AWS::Logs::LogGroup"), jsii.Number(2))
 			template.HasResourceProperties(jsii.String("AWS::Logs::LogGroup"), map[string]interface{}{
 				"LogGroupName": "/ecs/code-refactor",
 			})
@@ -285,9 +286,9 @@ func TestAppStack_CreatesExpectedResources(t *testing.T) {
 	// Test IAM and security
 	t.Run("IAM and Security", func(t *testing.T) {
 		t.Run("creates appropriate number of IAM roles", func(_ *testing.T) {
-			// Expected roles: Bedrock KB, Bedrock Agent, Lambda execution, ECS task execution, ECS task role, Lambda migration role, GitHub Actions role, ALB/ECS service role
+			// Expected roles: Bedrock KB, Bedrock Agent, Lambda execution, ECS task execution, ECS task role, Lambda migration role, GitHub Actions role, ALB/ECS service role, origin secret rotation role, schema migration provider role
 			// Note: OIDC provider is created manually outside CDK, so no role for that
-			template.ResourceCountIs(jsii.String("AWS::IAM::Role"), jsii.Number(8))
+			template.ResourceCountIs(jsii.String("AWS::IAM::Role"), jsii.Number(10))
 		})
 
 		t.Run("creates Bedrock Knowledge Base role with correct trust policy", func(_ *testing.T) {
@@ -433,3 +434,42 @@ func TestAppStack_ExposesCorrectOutputs(t *testing.T) {
 		}
 	})
 }
+
+func TestAppStack_KeepsLegacyLogicalIDs(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := NewAppStack(app, "TestStack", &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Region: jsii.String("us-east-1"),
+			},
+		},
+	})
+
+	// Act
+	resources, _ := (*assertions.Template_FromStack(stack.Stack, nil).ToJSON())["Resources"].(map[string]interface{})
+
+	// Assert
+	for logicalID, resourceType := range map[string]string{
+		"RefactorVpc306F85A2":                                       "AWS::EC2::VPC",
+		"RefactorVpcPublicSubnet1SubnetD123AB04":                    "AWS::EC2::Subnet",
+		"RefactorVpcPublicSubnet2Subnet0C6D3C76":                    "AWS::EC2::Subnet",
+		"coderefactoringdb92EA1605":                                 "AWS::RDS::DBCluster",
+		"coderefactoringdbwriter6794AC19":                           "AWS::RDS::DBInstance",
+		"coderefactoringdbSubnets85FC078B":                          "AWS::RDS::DBSubnetGroup",
+		"CodeRefactorDbSecret9279A3B3":                              "AWS::SecretsManager::Secret",
+		"CodeRefactorUserPool6707B5BB":                              "AWS::Cognito::UserPool",
+		"CodeRefactorUserPoolClient130FB122":                        "AWS::Cognito::UserPoolClient",
+		"CodeRefactorUserPoolDomain097E2250":                        "AWS::Cognito::UserPoolDomain",
+		"CodeRefactorBucketD1A5E682":                                "AWS::S3::Bucket",
+		"FrontendBucketEFE2E19C":                                    "AWS::S3::Bucket",
+		"RefactorEcrRepo44F40996":                                   "AWS::ECR::Repository",
+		"FargateLogGroupA4B4CA79":                                   "AWS::Logs::LogGroup",
+		"CodeRefactorBucketAutoDeleteObjectsCustomResource0F02CC85": "Custom::S3AutoDeleteObjects",
+	} {
+		resource, _ := resources[logicalID].(map[string]interface{})
+		if resource == nil || resource["Type"] != resourceType {
+			t.Errorf("%s is not a %s, so existing deployments would replace it", logicalID, resourceType)
+		}
+	}
+}
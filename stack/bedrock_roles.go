@@ -0,0 +1,188 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// BedrockRolesProps defines the properties for the BedrockRoles construct.
+type BedrockRolesProps struct {
+	// KnowledgeBaseBucket is the bucket the knowledge base ingests documents from. Required.
+	KnowledgeBaseBucket awss3.IBucket
+
+	// Database is the vector store the knowledge base writes embeddings to. Required.
+	Database *VectorDatabase
+
+	// FoundationModels lists the Bedrock models the agent may invoke. Defaults to FoundationModels.
+	FoundationModels []string
+
+	// Environment supplies the removal policy. Defaults to DevEnvironment.
+	Environment *EnvironmentConfig
+}
+
+// BedrockRoles are the service roles the backend hands to Bedrock when it creates knowledge bases and agents.
+type BedrockRoles struct {
+	constructs.Construct
+
+	// KnowledgeBaseRole may read the knowledge base bucket, embed documents with the vector stores' models and
+	// write to the vector database through the Data API.
+	KnowledgeBaseRole awsiam.IRole
+	// AgentRole may invoke the foundation models and query knowledge bases and prompts in this account.
+	AgentRole awsiam.IRole
+}
+
+// NewBedrockRoles creates the Bedrock knowledge base and agent roles.
+func NewBedrockRoles(scope constructs.Construct, id string, props *BedrockRolesProps) *BedrockRoles {
+	this := constructs.NewConstruct(scope, &id)
+	environment := environmentOrDefault(props.Environment)
+	foundationModels := props.FoundationModels
+	if len(foundationModels) == 0 {
+		foundationModels = FoundationModels
+	}
+
+	return &BedrockRoles{
+		Construct:         this,
+		KnowledgeBaseRole: createBedrockKnowledgeBaseRole(this, props.KnowledgeBaseBucket, props.Database, environment),
+		AgentRole:         createBedrockAgentRole(this, foundationModels, environment),
+	}
+}
+
+// createBedrockKnowledgeBaseRole creates the IAM role for Bedrock Knowledge Base
+func createBedrockKnowledgeBaseRole(scope constructs.Construct, bucket awss3.IBucket, database *VectorDatabase, environment *EnvironmentConfig) awsiam.IRole {
+	stack := awscdk.Stack_Of(scope)
+	region, account := *stack.Region(), *stack.Account()
+	embeddingModelResources := foundationModelArns(region, embeddingModels(database.VectorStores))
+
+	role := awsiam.NewRole(scope, jsii.String("BedrockKnowledgeBaseRole"), &awsiam.RoleProps{
+		AssumedBy: awsiam.NewServicePrincipal(jsii.String("bedrock.amazonaws.com"), nil),
+		InlinePolicies: &map[string]awsiam.PolicyDocument{
+			"BedrockKbPolicy": awsiam.NewPolicyDocument(&awsiam.PolicyDocumentProps{
+				Statements: &[]awsiam.PolicyStatement{
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Actions: &[]*string{
+							jsii.String("s3:GetObject"),
+							jsii.String("s3:ListBucket"),
+						},
+						Resources: &[]*string{
+							bucket.BucketArn(),
+							jsii.String(fmt.Sprintf("%s/*", *bucket.BucketArn())),
+						},
+					}),
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Actions: &[]*string{
+							jsii.String("secretsmanager:GetSecretValue"),
+						},
+						Resources: &[]*string{
+							database.CredentialsSecret.SecretArn(),
+						},
+					}),
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Actions: &[]*string{
+							jsii.String("rds-data:ExecuteStatement"),
+							jsii.String("rds-data:BatchExecuteStatement"),
+							jsii.String("rds-data:BeginTransaction"),
+							jsii.String("rds-data:CommitTransaction"),
+							jsii.String("rds-data:RollbackTransaction"),
+							jsii.String("rds-data:ExecuteSql"),
+							jsii.String("rds-data:DescribeTable"),
+						},
+						Resources: &[]*string{
+							database.Cluster.ClusterArn(),
+						},
+					}),
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Actions: &[]*string{
+							jsii.String("rds:DescribeDBClusters"),
+							jsii.String("rds:DescribeDBInstances"),
+						},
+						Resources: &[]*string{
+							database.Cluster.ClusterArn(),
+							jsii.String(fmt.Sprintf("arn:aws:rds:%s:%s:db:*", region, account)),
+						},
+					}),
+					// Ingestion embeds documents with the vector stores' models
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Actions: &[]*string{
+							jsii.String("bedrock:InvokeModel"),
+						},
+						Resources: &embeddingModelResources,
+					}),
+				},
+			}),
+		},
+	})
+
+	// Apply removal policy to Bedrock Knowledge Base role for clean deletion
+	role.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	return role
+}
+
+// createBedrockAgentRole creates the IAM role for Bedrock Agent
+func createBedrockAgentRole(scope constructs.Construct, foundationModels []string, environment *EnvironmentConfig) awsiam.IRole {
+	stack := awscdk.Stack_Of(scope)
+	region, account := *stack.Region(), *stack.Account()
+
+	foundationModelResources := foundationModelArns(region, foundationModels)
+
+	role := awsiam.NewRole(scope, jsii.String("BedrockAgentRole"), &awsiam.RoleProps{
+		AssumedBy: awsiam.NewServicePrincipal(jsii.String("bedrock.amazonaws.com"), nil),
+		InlinePolicies: &map[string]awsiam.PolicyDocument{
+			"BedrockAgentPolicy": awsiam.NewPolicyDocument(&awsiam.PolicyDocumentProps{
+				Statements: &[]awsiam.PolicyStatement{
+					// Model invocation permissions
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Sid:    jsii.String("AgentModelInvocationPermissions"),
+						Effect: awsiam.Effect_ALLOW,
+						Actions: &[]*string{
+							jsii.String("bedrock:InvokeModel"),
+						},
+						Resources: &foundationModelResources,
+					}),
+					// Knowledge base query permissions
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Sid:    jsii.String("AgentKnowledgeBaseQuery"),
+						Effect: awsiam.Effect_ALLOW,
+						Actions: &[]*string{
+							jsii.String("bedrock:Retrieve"),
+							jsii.String("bedrock:RetrieveAndGenerate"),
+						},
+						Resources: &[]*string{
+							jsii.String(fmt.Sprintf("arn:aws:bedrock:%s:%s:knowledge-base/*", region, account)),
+						},
+					}),
+					// Prompt management console access
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Sid:    jsii.String("AgentPromptManagementConsole"),
+						Effect: awsiam.Effect_ALLOW,
+						Actions: &[]*string{
+							jsii.String("bedrock:GetPrompt"),
+						},
+						Resources: &[]*string{
+							jsii.String(fmt.Sprintf("arn:aws:bedrock:%s:%s:prompt/*", region, account)),
+						},
+					}),
+				},
+			}),
+		},
+	})
+
+	// Apply removal policy to Bedrock Agent role for clean deletion
+	role.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	return role
+}
+
+// foundationModelArns returns the ARNs of the Bedrock foundation models in the region.
+func foundationModelArns(region string, models []string) []*string {
+	arns := make([]*string, len(models))
+	for i, model := range models {
+		arns[i] = jsii.String(fmt.Sprintf("arn:aws:bedrock:%s::foundation-model/%s", region, model))
+	}
+	return arns
+}
@@ -14,11 +14,17 @@ const (
 	DefaultResourceTagValue = "CodeRefactoring"
 
 	// SchemaVersion is a version string for the database schema.
-	// Increment this string to trigger new migrations.
+	// Increment this string to run the migration Lambda on the next deployment.
 	SchemaVersion = "v1" // Change to "v2", "v3", etc., for future schema updates
 )
 
 var (
+	// GitHubRepositories lists the repositories whose GitHub Actions workflows may assume the deployment role.
+	GitHubRepositories = []string{
+		"kazemisoroush/code-refactoring-tool",
+		"kazemisoroush/code-refactoring-ui",
+	}
+
 	// FoundationModels is a list of foundation models to be used in the application.
 	FoundationModels = []string{
 		// Anthropic Claude
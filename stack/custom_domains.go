@@ -0,0 +1,76 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscertificatemanager"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsroute53"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// globalCertificateRegion is the only region CloudFront and Cognito custom domains accept certificates from.
+const globalCertificateRegion = "us-east-1"
+
+// DomainConfig serves the public entry points under custom domain names in a Route 53 hosted zone. Each name
+// gets a DNS validated certificate and an alias record; an empty name keeps the AWS generated one.
+type DomainConfig struct {
+	// HostedZone is the public zone the names belong to. Required.
+	HostedZone HostedZoneConfig
+
+	// APIDomainName serves the API at its root, such as api.example.com, instead of the execute-api URL.
+	APIDomainName string
+
+	// AuthDomainName serves the Cognito hosted UI, such as auth.example.com, instead of the amazoncognito.com
+	// prefix domain. Cognito requires the parent domain to resolve to an A record.
+	AuthDomainName string
+
+	// FrontendDomainName serves the frontend, such as app.example.com, instead of the cloudfront.net name.
+	FrontendDomainName string
+}
+
+// hostedZone references the configured zone without a lookup.
+func (d *DomainConfig) hostedZone(scope constructs.Construct) awsroute53.IHostedZone {
+	return awsroute53.HostedZone_FromHostedZoneAttributes(scope, jsii.String("HostedZone"), &awsroute53.HostedZoneAttributes{
+		HostedZoneId: jsii.String(d.HostedZone.ID),
+		ZoneName:     jsii.String(d.HostedZone.Name),
+	})
+}
+
+// newDomainCertificate requests a certificate for the domain name, validated through the zone, in the stack's
+// region.
+func newDomainCertificate(scope constructs.Construct, id, domainName string, zone awsroute53.IHostedZone, environment *EnvironmentConfig) awscertificatemanager.ICertificate {
+	certificate := awscertificatemanager.NewCertificate(scope, jsii.String(id), &awscertificatemanager.CertificateProps{
+		DomainName: jsii.String(domainName),
+		Validation: awscertificatemanager.CertificateValidation_FromDns(zone),
+	})
+	certificate.ApplyRemovalPolicy(environment.RemovalPolicy)
+	return certificate
+}
+
+// newGlobalCertificate requests a certificate for the domain name in us-east-1, as CloudFront and Cognito require.
+// Outside us-east-1 a custom resource requests it there, which keeps the certificate in the stack that uses it
+// rather than in a separate us-east-1 stack wired through cross-region references.
+func newGlobalCertificate(scope constructs.Construct, id, domainName string, zone awsroute53.IHostedZone, environment *EnvironmentConfig) awscertificatemanager.ICertificate {
+	region := awscdk.Stack_Of(scope).Region()
+	if !*awscdk.Token_IsUnresolved(region) && *region == globalCertificateRegion {
+		return newDomainCertificate(scope, id, domainName, zone, environment)
+	}
+
+	//nolint:staticcheck // DnsValidatedCertificate is deprecated but Certificate cannot be created in another region
+	certificate := awscertificatemanager.NewDnsValidatedCertificate(scope, jsii.String(id), &awscertificatemanager.DnsValidatedCertificateProps{
+		DomainName:            jsii.String(domainName),
+		HostedZone:            zone,
+		Region:                jsii.String(globalCertificateRegion),
+		CleanupRoute53Records: jsii.Bool(true),
+	})
+	certificate.ApplyRemovalPolicy(environment.RemovalPolicy)
+	SuppressSecurityRule(certificate, SecurityRuleWildcardIAMResource, "The requestor cannot name the certificate before requesting it, and Route 53 change IDs are only known once the records are written.")
+	return certificate
+}
+
+// domainURL is the HTTPS URL of a domain name.
+func domainURL(domainName string) string {
+	return fmt.Sprintf("https://%s", domainName)
+}
@@ -0,0 +1,132 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestAppStack_CustomDomains(t *testing.T) {
+	domains := &DomainConfig{
+		HostedZone:         HostedZoneConfig{ID: "Z0123456789ABCDEFGHIJ", Name: "example.com"},
+		APIDomainName:      "api.example.com",
+		AuthDomainName:     "auth.example.com",
+		FrontendDomainName: "app.example.com",
+	}
+	newStack := func(region string) *AppStack {
+		app := awscdk.NewApp(nil)
+		return NewAppStack(app, "TestStack", &AppStackProps{
+			StackProps: awscdk.StackProps{
+				Env: &awscdk.Environment{
+					Account: jsii.String("123456789012"),
+					Region:  jsii.String(region),
+				},
+			},
+			Domains: domains,
+		})
+	}
+
+	t.Run("outside us-east-1", func(t *testing.T) {
+		// Arrange
+		stack := newStack("eu-west-1")
+
+		// Act
+		template := assertions.Template_FromStack(stack.Stack, nil)
+
+		// Assert
+		t.Run("maps the API stage to the root of its regional domain", func(_ *testing.T) {
+			template.HasResourceProperties(jsii.String("AWS::ApiGateway::DomainName"), map[string]interface{}{
+				"DomainName":            "api.example.com",
+				"SecurityPolicy":        "TLS_1_2",
+				"EndpointConfiguration": map[string]interface{}{"Types": []interface{}{"REGIONAL"}},
+			})
+			template.ResourceCountIs(jsii.String("AWS::ApiGateway::BasePathMapping"), jsii.Number(1))
+			template.ResourceCountIs(jsii.String("AWS::CertificateManager::Certificate"), jsii.Number(1))
+			template.HasResourceProperties(jsii.String("AWS::CertificateManager::Certificate"), map[string]interface{}{
+				"DomainName": "api.example.com",
+			})
+		})
+
+		t.Run("requests the CloudFront and Cognito certificates in us-east-1", func(_ *testing.T) {
+			for _, domainName := range []string{"auth.example.com", "app.example.com"} {
+				template.HasResourceProperties(jsii.String("AWS::CloudFormation::CustomResource"), map[string]interface{}{
+					"DomainName":   domainName,
+					"Region":       "us-east-1",
+					"HostedZoneId": "Z0123456789ABCDEFGHIJ",
+				})
+			}
+		})
+
+		t.Run("serves the hosted UI on the auth domain", func(_ *testing.T) {
+			template.HasResourceProperties(jsii.String("AWS::Cognito::UserPoolDomain"), map[string]interface{}{
+				"Domain":             "auth.example.com",
+				"CustomDomainConfig": assertions.Match_ObjectLike(&map[string]interface{}{}),
+			})
+		})
+
+		t.Run("redirects the hosted UI back to the frontend domain", func(_ *testing.T) {
+			template.HasResourceProperties(jsii.String("AWS::Cognito::UserPoolClient"), map[string]interface{}{
+				"CallbackURLs": []interface{}{"https://localhost:3000/callback", "https://app.example.com/callback"},
+				"LogoutURLs":   []interface{}{"https://localhost:3000/logout", "https://app.example.com/logout"},
+			})
+		})
+
+		t.Run("serves the frontend on its domain", func(_ *testing.T) {
+			template.HasResourceProperties(jsii.String("AWS::CloudFront::Distribution"), map[string]interface{}{
+				"DistributionConfig": map[string]interface{}{
+					"Aliases": []interface{}{"app.example.com"},
+					"ViewerCertificate": map[string]interface{}{
+						"MinimumProtocolVersion": "TLSv1.2_2021",
+						"SslSupportMethod":       "sni-only",
+					},
+				},
+			})
+		})
+
+		t.Run("points every name at its entry point", func(_ *testing.T) {
+			for _, record := range []struct{ name, recordType string }{
+				{"api.example.com.", "A"},
+				{"auth.example.com.", "A"},
+				{"app.example.com.", "A"},
+				{"app.example.com.", "AAAA"},
+			} {
+				template.HasResourceProperties(jsii.String("AWS::Route53::RecordSet"), map[string]interface{}{
+					"Name":         record.name,
+					"Type":         record.recordType,
+					"HostedZoneId": "Z0123456789ABCDEFGHIJ",
+					"AliasTarget":  assertions.Match_ObjectLike(&map[string]interface{}{}),
+				})
+			}
+		})
+
+		t.Run("publishes the friendly URLs", func(t *testing.T) {
+			template.HasOutput(jsii.String(OutputAPIGatewayURL), map[string]interface{}{"Value": "https://api.example.com/"})
+			template.HasOutput(jsii.String(OutputFrontendURL), map[string]interface{}{"Value": "https://app.example.com"})
+			template.HasResourceProperties(jsii.String("AWS::SSM::Parameter"), map[string]interface{}{
+				"Name":  "/code-refactor/frontend/api-base-url",
+				"Value": "https://api.example.com/",
+			})
+			template.HasResourceProperties(jsii.String("AWS::SSM::Parameter"), map[string]interface{}{
+				"Name":  "/code-refactor/frontend/cloudfront-domain",
+				"Value": "https://app.example.com",
+			})
+			if stack.APIGatewayURL != "https://api.example.com/" {
+				t.Errorf("APIGatewayURL = %q", stack.APIGatewayURL)
+			}
+		})
+	})
+
+	t.Run("in us-east-1 every certificate is a plain ACM certificate", func(_ *testing.T) {
+		// Arrange
+		stack := newStack("us-east-1")
+
+		// Act
+		template := assertions.Template_FromStack(stack.Stack, nil)
+
+		// Assert
+		template.ResourceCountIs(jsii.String("AWS::CertificateManager::Certificate"), jsii.Number(3))
+		template.ResourceCountIs(jsii.String("AWS::CloudFormation::CustomResource"), jsii.Number(0))
+	})
+}
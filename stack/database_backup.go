@@ -0,0 +1,193 @@
+package stack
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsbackup"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsevents"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsrds"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssecretsmanager"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Default Aurora windows, in UTC. The backup window ends before the maintenance window starts, as RDS requires.
+const (
+	DefaultDatabaseBackupWindow      = "03:00-04:00"
+	DefaultDatabaseMaintenanceWindow = "sun:04:30-sun:05:30"
+)
+
+// backupPlanHour is the UTC hour AWS Backup starts the daily recovery point, after the default backup and
+// maintenance windows.
+const backupPlanHour = "6"
+
+// DatabaseBackupConfig selects how the Aurora cluster is backed up. Automated backups allow point-in-time
+// recovery to any second within the retention period, and every snapshot carries the cluster's tags.
+type DatabaseBackupConfig struct {
+	// RetentionDays is how many days of automated backups Aurora keeps, from 1 to 35. Defaults to the
+	// environment's DatabaseBackupRetentionDays.
+	RetentionDays int
+
+	// PreferredBackupWindow is the daily UTC window automated backups start in, such as 03:00-04:00. It must last
+	// at least 30 minutes and not overlap the maintenance window. Defaults to 03:00-04:00.
+	PreferredBackupWindow string
+
+	// PreferredMaintenanceWindow is the weekly UTC window for engine patches, such as sun:04:30-sun:05:30.
+	// Defaults to sun:04:30-sun:05:30.
+	PreferredMaintenanceWindow string
+
+	// Plan also backs the cluster up with AWS Backup, whose recovery points live in a vault outside the cluster's
+	// lifecycle. Defaults to none.
+	Plan *BackupPlanConfig
+}
+
+// BackupPlanConfig takes a daily AWS Backup recovery point of the cluster into a dedicated vault.
+type BackupPlanConfig struct {
+	// RetentionDays is how long the daily recovery points are kept. Required.
+	RetentionDays int
+
+	// ContinuousBackup adds point-in-time recovery from the vault, which limits RetentionDays to 35.
+	ContinuousBackup bool
+
+	// VaultLock stops anyone from deleting recovery points early or shortening their retention. Defaults to none.
+	VaultLock *VaultLockConfig
+
+	// CopyToVaultArn copies every recovery point to this vault, typically in another region of the same account,
+	// which must exist beforehand.
+	CopyToVaultArn string
+
+	// CopyRetentionDays is how long the copies are kept. Defaults to RetentionDays.
+	CopyRetentionDays int
+}
+
+// VaultLockConfig locks the backup vault. Without ChangeableForDays the lock is in governance mode, which users
+// with the right IAM permissions can remove; with it the lock turns into compliance mode after that many days,
+// after which nobody, the root user included, can remove it or delete recovery points early.
+type VaultLockConfig struct {
+	// MinRetentionDays is the shortest retention a recovery point in the vault may have. Required.
+	MinRetentionDays int
+
+	// MaxRetentionDays is the longest retention a recovery point in the vault may have. Zero sets no maximum.
+	MaxRetentionDays int
+
+	// ChangeableForDays is the grace period, at least 3 days, before the lock becomes immutable. Zero keeps it in
+	// governance mode.
+	ChangeableForDays int
+}
+
+// databaseBackupOrDefault returns the backup settings, using the environment's retention and the default windows
+// for unset fields.
+func databaseBackupOrDefault(backup *DatabaseBackupConfig, environment *EnvironmentConfig) DatabaseBackupConfig {
+	var resolved DatabaseBackupConfig
+	if backup != nil {
+		resolved = *backup
+	}
+	if resolved.RetentionDays == 0 {
+		resolved.RetentionDays = max(environment.DatabaseBackupRetentionDays, 1)
+	}
+	if resolved.PreferredBackupWindow == "" {
+		resolved.PreferredBackupWindow = DefaultDatabaseBackupWindow
+	}
+	if resolved.PreferredMaintenanceWindow == "" {
+		resolved.PreferredMaintenanceWindow = DefaultDatabaseMaintenanceWindow
+	}
+	return resolved
+}
+
+// lockConfiguration is the vault lock in CDK terms.
+func (l *VaultLockConfig) lockConfiguration() *awsbackup.LockConfiguration {
+	lock := &awsbackup.LockConfiguration{
+		MinRetention: awscdk.Duration_Days(jsii.Number(l.MinRetentionDays)),
+	}
+	if l.MaxRetentionDays != 0 {
+		lock.MaxRetention = awscdk.Duration_Days(jsii.Number(l.MaxRetentionDays))
+	}
+	if l.ChangeableForDays != 0 {
+		lock.ChangeableFor = awscdk.Duration_Days(jsii.Number(l.ChangeableForDays))
+	}
+	return lock
+}
+
+// backupProps are the cluster's automated backup settings.
+func (b DatabaseBackupConfig) backupProps() *awsrds.BackupProps {
+	return &awsrds.BackupProps{
+		Retention:       awscdk.Duration_Days(jsii.Number(b.RetentionDays)),
+		PreferredWindow: jsii.String(b.PreferredBackupWindow),
+	}
+}
+
+// newDatabaseCluster creates the cluster with the given properties, from the cluster snapshot when one is given
+// instead of an empty volume. A restored cluster keeps the snapshot's databases and master user, whose password is
+// reset to the one in the credentials secret.
+func newDatabaseCluster(scope constructs.Construct, id string, snapshotIdentifier string, props *awsrds.DatabaseClusterProps, credentialsSecret awssecretsmanager.ISecret) awsrds.IDatabaseCluster {
+	if snapshotIdentifier == "" {
+		return awsrds.NewDatabaseCluster(scope, jsii.String(id), props)
+	}
+	return awsrds.NewDatabaseClusterFromSnapshot(scope, jsii.String(id), &awsrds.DatabaseClusterFromSnapshotProps{
+		SnapshotIdentifier:         jsii.String(snapshotIdentifier),
+		SnapshotCredentials:        awsrds.SnapshotCredentials_FromSecret(credentialsSecret),
+		Engine:                     props.Engine,
+		Writer:                     props.Writer,
+		Readers:                    props.Readers,
+		Vpc:                        props.Vpc,
+		VpcSubnets:                 props.VpcSubnets,
+		Port:                       props.Port,
+		RemovalPolicy:              props.RemovalPolicy,
+		DeletionProtection:         props.DeletionProtection,
+		ClusterIdentifier:          props.ClusterIdentifier,
+		EnableDataApi:              props.EnableDataApi,
+		ServerlessV2MinCapacity:    props.ServerlessV2MinCapacity,
+		ServerlessV2MaxCapacity:    props.ServerlessV2MaxCapacity,
+		StorageEncrypted:           props.StorageEncrypted,
+		Backup:                     props.Backup,
+		PreferredMaintenanceWindow: props.PreferredMaintenanceWindow,
+		CopyTagsToSnapshot:         props.CopyTagsToSnapshot,
+	})
+}
+
+// addBackupPlan backs the cluster up daily into a vault of its own when the settings include a plan, and copies
+// each recovery point when a destination vault is configured.
+func (d *VectorDatabase) addBackupPlan(backup DatabaseBackupConfig, naming *Naming) {
+	plan := backup.Plan
+	if plan == nil {
+		return
+	}
+
+	var lock *awsbackup.LockConfiguration
+	if plan.VaultLock != nil {
+		lock = plan.VaultLock.lockConfiguration()
+	}
+	vault := awsbackup.NewBackupVault(d.Construct, jsii.String("BackupVault"), &awsbackup.BackupVaultProps{
+		BackupVaultName:   jsii.String(naming.Name("db-backups")),
+		LockConfiguration: lock,
+		// Recovery points must outlive the stack, and a vault still holding any cannot be deleted anyway
+		RemovalPolicy: awscdk.RemovalPolicy_RETAIN,
+	})
+
+	rule := &awsbackup.BackupPlanRuleProps{
+		RuleName:               jsii.String("Daily"),
+		ScheduleExpression:     awsevents.Schedule_Cron(&awsevents.CronOptions{Hour: jsii.String(backupPlanHour), Minute: jsii.String("0")}),
+		DeleteAfter:            awscdk.Duration_Days(jsii.Number(plan.RetentionDays)),
+		EnableContinuousBackup: jsii.Bool(plan.ContinuousBackup),
+	}
+	if plan.CopyToVaultArn != "" {
+		copyRetentionDays := plan.CopyRetentionDays
+		if copyRetentionDays == 0 {
+			copyRetentionDays = plan.RetentionDays
+		}
+		rule.CopyActions = &[]*awsbackup.BackupPlanCopyActionProps{{
+			DestinationBackupVault: awsbackup.BackupVault_FromBackupVaultArn(d.Construct, jsii.String("BackupCopyVault"), jsii.String(plan.CopyToVaultArn)),
+			DeleteAfter:            awscdk.Duration_Days(jsii.Number(copyRetentionDays)),
+		}}
+	}
+
+	backupPlan := awsbackup.NewBackupPlan(d.Construct, jsii.String("BackupPlan"), &awsbackup.BackupPlanProps{
+		BackupPlanName:  jsii.String(naming.Name("db-backup-plan")),
+		BackupVault:     vault,
+		BackupPlanRules: &[]awsbackup.BackupPlanRule{awsbackup.NewBackupPlanRule(rule)},
+	})
+	backupPlan.AddSelection(jsii.String("Cluster"), &awsbackup.BackupSelectionOptions{
+		Resources: &[]awsbackup.BackupResource{awsbackup.BackupResource_FromRdsDatabaseCluster(d.Cluster)},
+	})
+
+	d.BackupVault = vault
+}
@@ -0,0 +1,81 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsrds"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// DatabaseReaderConfig declares an Aurora reader instance. Readers serve the cluster's reader endpoint and take
+// over when the writer fails.
+type DatabaseReaderConfig struct {
+	// InstanceType makes the reader a provisioned instance of this class, such as r6g.large. Empty makes it a
+	// Serverless v2 instance within the environment's capacity range.
+	InstanceType string
+
+	// PromotionTier orders the readers for failover, from 0, promoted first, to 15. Serverless v2 readers in tiers 0
+	// and 1 scale with the writer so they can take over at its size; the rest scale on their own load.
+	PromotionTier int
+}
+
+// instance returns the cluster instance for the reader in the availability zone.
+func (r DatabaseReaderConfig) instance(id string, availabilityZone *string) awsrds.IClusterInstance {
+	if r.InstanceType == "" {
+		return awsrds.ClusterInstance_ServerlessV2(jsii.String(id), &awsrds.ServerlessV2ClusterInstanceProps{
+			AutoMinorVersionUpgrade: jsii.Bool(true),
+			AvailabilityZone:        availabilityZone,
+			ScaleWithWriter:         jsii.Bool(r.PromotionTier <= 1),
+		})
+	}
+	return awsrds.ClusterInstance_Provisioned(jsii.String(id), &awsrds.ProvisionedClusterInstanceProps{
+		AutoMinorVersionUpgrade: jsii.Bool(true),
+		AvailabilityZone:        availabilityZone,
+		InstanceType:            awsec2.NewInstanceType(jsii.String(r.InstanceType)),
+		PromotionTier:           jsii.Number(r.PromotionTier),
+	})
+}
+
+// databaseInstances returns the writer and reader instances. With readers, the writer is pinned to the first zone
+// and the readers round-robin to the zones starting with the second, so the cluster spans every zone. Pinning the
+// writer of an existing cluster replaces it, so without readers it stays where Aurora placed it.
+func databaseInstances(readers []DatabaseReaderConfig, zones []*string) (awsrds.IClusterInstance, []awsrds.IClusterInstance) {
+	var writerZone *string
+	if len(readers) > 0 && len(zones) > 0 {
+		writerZone = zones[0]
+	}
+	writer := awsrds.ClusterInstance_ServerlessV2(jsii.String("writer"), &awsrds.ServerlessV2ClusterInstanceProps{
+		AutoMinorVersionUpgrade: jsii.Bool(true),
+		AvailabilityZone:        writerZone,
+	})
+
+	instances := make([]awsrds.IClusterInstance, len(readers))
+	for i, reader := range readers {
+		var zone *string
+		if len(zones) > 0 {
+			zone = zones[(i+1)%len(zones)]
+		}
+		instances[i] = reader.instance(readerID(i), zone)
+	}
+	return writer, instances
+}
+
+// applyPromotionTiers sets the configured promotion tier of the Serverless v2 readers, which CDK otherwise derives
+// from whether they scale with the writer.
+func applyPromotionTiers(cluster awsrds.IDatabaseCluster, readers []DatabaseReaderConfig) {
+	for i, reader := range readers {
+		if reader.InstanceType != "" {
+			continue
+		}
+		instance := cluster.Node().FindChild(jsii.String(readerID(i)))
+		resource := instance.Node().DefaultChild().(awscdk.CfnResource)
+		resource.AddPropertyOverride(jsii.String("PromotionTier"), jsii.Number(reader.PromotionTier))
+	}
+}
+
+// readerID is the construct ID of the reader at the index.
+func readerID(index int) string {
+	return fmt.Sprintf("reader%d", index+1)
+}
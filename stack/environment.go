@@ -0,0 +1,186 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+)
+
+const (
+	// EnvironmentDev is the name of the development environment profile.
+	EnvironmentDev = "dev"
+
+	// EnvironmentStaging is the name of the staging environment profile.
+	EnvironmentStaging = "staging"
+
+	// EnvironmentProd is the name of the production environment profile.
+	EnvironmentProd = "prod"
+)
+
+// logRetentionDays maps the retention periods supported by CloudWatch Logs to their CDK values.
+var logRetentionDays = map[int]awslogs.RetentionDays{
+	1:    awslogs.RetentionDays_ONE_DAY,
+	3:    awslogs.RetentionDays_THREE_DAYS,
+	5:    awslogs.RetentionDays_FIVE_DAYS,
+	7:    awslogs.RetentionDays_ONE_WEEK,
+	14:   awslogs.RetentionDays_TWO_WEEKS,
+	30:   awslogs.RetentionDays_ONE_MONTH,
+	60:   awslogs.RetentionDays_TWO_MONTHS,
+	90:   awslogs.RetentionDays_THREE_MONTHS,
+	120:  awslogs.RetentionDays_FOUR_MONTHS,
+	150:  awslogs.RetentionDays_FIVE_MONTHS,
+	180:  awslogs.RetentionDays_SIX_MONTHS,
+	365:  awslogs.RetentionDays_ONE_YEAR,
+	400:  awslogs.RetentionDays_THIRTEEN_MONTHS,
+	545:  awslogs.RetentionDays_EIGHTEEN_MONTHS,
+	731:  awslogs.RetentionDays_TWO_YEARS,
+	1096: awslogs.RetentionDays_THREE_YEARS,
+	1827: awslogs.RetentionDays_FIVE_YEARS,
+	2192: awslogs.RetentionDays_SIX_YEARS,
+	2557: awslogs.RetentionDays_SEVEN_YEARS,
+	2922: awslogs.RetentionDays_EIGHT_YEARS,
+	3288: awslogs.RetentionDays_NINE_YEARS,
+	3653: awslogs.RetentionDays_TEN_YEARS,
+}
+
+// EnvironmentConfig holds the sizing and lifecycle settings that differ between environments.
+type EnvironmentConfig struct {
+	// Name identifies the environment, e.g. "dev" or "prod".
+	Name string
+
+	// DatabaseMinCapacity is the minimum Aurora Serverless v2 capacity in ACUs.
+	DatabaseMinCapacity float64
+	// DatabaseMaxCapacity is the maximum Aurora Serverless v2 capacity in ACUs.
+	DatabaseMaxCapacity float64
+	// DatabaseReaders are the Aurora reader instances added to the writer, spread over the database subnets' zones.
+	DatabaseReaders []DatabaseReaderConfig
+	// DatabaseBackupRetentionDays is how many days of automated Aurora backups are kept for point-in-time recovery.
+	// Zero keeps Aurora's minimum of one day.
+	DatabaseBackupRetentionDays int
+
+	// TaskCPU is the Fargate task CPU in CPU units.
+	TaskCPU float64
+	// TaskMemoryMiB is the Fargate task memory in MiB.
+	TaskMemoryMiB float64
+	// DesiredCount is the number of Fargate tasks to keep running.
+	DesiredCount float64
+
+	// LogRetention is how long CloudWatch logs are kept.
+	LogRetention awslogs.RetentionDays
+	// RemovalPolicy is applied to resources when they are removed from the stack.
+	RemovalPolicy awscdk.RemovalPolicy
+	// DataProtection keeps data when the stack is destroyed: Aurora is snapshotted, buckets, repositories,
+	// the user pool and secrets are retained, and deletion protection is enabled.
+	DataProtection bool
+	// EnforceSecurityPolicy turns security policy findings into synthesis errors instead of warnings.
+	EnforceSecurityPolicy bool
+}
+
+// DevEnvironment returns the profile for cheap, disposable development stacks.
+func DevEnvironment() *EnvironmentConfig {
+	return &EnvironmentConfig{
+		Name:                        EnvironmentDev,
+		DatabaseMinCapacity:         0.5,
+		DatabaseMaxCapacity:         4,
+		DatabaseBackupRetentionDays: 1,
+		TaskCPU:                     512,
+		TaskMemoryMiB:               1024,
+		DesiredCount:                1,
+		LogRetention:                awslogs.RetentionDays_ONE_WEEK,
+		RemovalPolicy:               awscdk.RemovalPolicy_DESTROY,
+	}
+}
+
+// StagingEnvironment returns the profile for production-like staging stacks.
+func StagingEnvironment() *EnvironmentConfig {
+	return &EnvironmentConfig{
+		Name:                        EnvironmentStaging,
+		DatabaseMinCapacity:         0.5,
+		DatabaseMaxCapacity:         8,
+		DatabaseBackupRetentionDays: 7,
+		TaskCPU:                     1024,
+		TaskMemoryMiB:               2048,
+		DesiredCount:                1,
+		LogRetention:                awslogs.RetentionDays_ONE_MONTH,
+		RemovalPolicy:               awscdk.RemovalPolicy_DESTROY,
+	}
+}
+
+// ProdEnvironment returns the profile for durable production stacks.
+func ProdEnvironment() *EnvironmentConfig {
+	return &EnvironmentConfig{
+		Name:                        EnvironmentProd,
+		DatabaseMinCapacity:         1,
+		DatabaseMaxCapacity:         16,
+		DatabaseReaders:             []DatabaseReaderConfig{{PromotionTier: 1}},
+		DatabaseBackupRetentionDays: 35,
+		TaskCPU:                     1024,
+		TaskMemoryMiB:               2048,
+		DesiredCount:                2,
+		LogRetention:                awslogs.RetentionDays_ONE_YEAR,
+		RemovalPolicy:               awscdk.RemovalPolicy_RETAIN,
+		DataProtection:              true,
+		EnforceSecurityPolicy:       true,
+	}
+}
+
+// EnvironmentByName returns the built-in profile with the given name.
+func EnvironmentByName(name string) (*EnvironmentConfig, error) {
+	switch name {
+	case EnvironmentDev:
+		return DevEnvironment(), nil
+	case EnvironmentStaging:
+		return StagingEnvironment(), nil
+	case EnvironmentProd:
+		return ProdEnvironment(), nil
+	default:
+		return nil, fmt.Errorf("unknown environment %q", name)
+	}
+}
+
+// statefulRemovalPolicy is the removal policy for resources holding data that cannot be recreated.
+func (e *EnvironmentConfig) statefulRemovalPolicy() awscdk.RemovalPolicy {
+	if e.DataProtection {
+		return awscdk.RemovalPolicy_RETAIN
+	}
+	return e.RemovalPolicy
+}
+
+// databaseRemovalPolicy is the removal policy for the Aurora cluster, which is snapshotted under data protection.
+func (e *EnvironmentConfig) databaseRemovalPolicy() awscdk.RemovalPolicy {
+	if e.DataProtection {
+		return awscdk.RemovalPolicy_SNAPSHOT
+	}
+	return e.RemovalPolicy
+}
+
+// autoDeleteOnRemoval reports whether stateful resource contents should be emptied so the resource can be destroyed.
+func (e *EnvironmentConfig) autoDeleteOnRemoval() bool {
+	return e.statefulRemovalPolicy() == awscdk.RemovalPolicy_DESTROY
+}
+
+// LogRetentionByDays returns the CloudWatch Logs retention period of the given number of days, and false when
+// CloudWatch Logs does not support that period.
+func LogRetentionByDays(days int) (awslogs.RetentionDays, bool) {
+	retention, ok := logRetentionDays[days]
+	return retention, ok
+}
+
+// logRetentionInDays returns the number of days LogRetention keeps logs, and false when they never expire.
+func (e *EnvironmentConfig) logRetentionInDays() (int, bool) {
+	for days, retention := range logRetentionDays {
+		if retention == e.LogRetention {
+			return days, true
+		}
+	}
+	return 0, false
+}
+
+// environmentOrDefault returns the given environment, or DevEnvironment when it is nil.
+func environmentOrDefault(environment *EnvironmentConfig) *EnvironmentConfig {
+	if environment == nil {
+		return DevEnvironment()
+	}
+	return environment
+}
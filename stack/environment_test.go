@@ -0,0 +1,167 @@
+package stack
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestEnvironmentByName(t *testing.T) {
+	t.Run("returns built-in profiles", func(t *testing.T) {
+		for _, name := range []string{EnvironmentDev, EnvironmentStaging, EnvironmentProd} {
+			// Act
+			environment, err := EnvironmentByName(name)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("EnvironmentByName(%q) returned error: %v", name, err)
+			}
+			if environment.Name != name {
+				t.Errorf("EnvironmentByName(%q).Name = %q", name, environment.Name)
+			}
+		}
+	})
+
+	t.Run("rejects unknown profiles", func(t *testing.T) {
+		// Act
+		_, err := EnvironmentByName("qa")
+
+		// Assert
+		if err == nil {
+			t.Error("expected error for unknown environment")
+		}
+	})
+}
+
+func TestAppStack_AppliesEnvironmentProfile(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := NewAppStack(app, "ProdStack", &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Region: jsii.String("us-east-1"),
+			},
+		},
+		Environment: ProdEnvironment(),
+	})
+
+	// Act
+	template := assertions.Template_FromStack(stack.Stack, nil)
+
+	// Assert
+	t.Run("sizes the database from the profile", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::RDS::DBCluster"), map[string]interface{}{
+			"ServerlessV2ScalingConfiguration": map[string]interface{}{
+				"MinCapacity": 1,
+				"MaxCapacity": 16,
+			},
+		})
+	})
+
+	t.Run("sizes the Fargate service from the profile", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::ECS::TaskDefinition"), map[string]interface{}{
+			"Cpu":    "1024",
+			"Memory": "2048",
+		})
+		template.HasResourceProperties(jsii.String("AWS::ECS::Service"), map[string]interface{}{
+			"DesiredCount": 2,
+		})
+	})
+
+	t.Run("keeps logs for the profile retention", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::Logs::LogGroup"), map[string]interface{}{
+			"RetentionInDays": 365,
+		})
+	})
+
+	t.Run("retains resources on removal", func(_ *testing.T) {
+		template.HasResource(jsii.String("AWS::ECS::Cluster"), map[string]interface{}{
+			"DeletionPolicy": "Retain",
+		})
+		template.HasResource(jsii.String("AWS::S3::Bucket"), map[string]interface{}{
+			"DeletionPolicy": "Retain",
+		})
+	})
+}
+
+func TestAppStack_DataProtectionKeepsStatefulResources(t *testing.T) {
+	// Arrange
+	environment := DevEnvironment()
+	environment.DataProtection = true
+	props := &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Region: jsii.String("us-east-1"),
+			},
+		},
+		Environment: environment,
+	}
+	app := awscdk.NewApp(nil)
+	single := NewAppStack(app, "SingleStack", props)
+	split := NewSplitAppStacks(app, "SplitStack", props)
+
+	// Act
+	templates := map[string]assertions.Template{
+		"single": assertions.Template_FromStack(single.Stack, nil),
+		"data":   assertions.Template_FromStack(split.Data.Stack, nil),
+		"edge":   assertions.Template_FromStack(split.Edge.Stack, nil),
+	}
+
+	// Assert
+	for name, template := range templates {
+		t.Run(name+" stack cannot destroy stateful resources", func(t *testing.T) {
+			for logicalID, resource := range templateResources(template) {
+				allowed, stateful := statefulDeletionPolicies[resource.Type]
+				if stateful && !allowed[resource.DeletionPolicy] {
+					t.Errorf("%s (%s) has DeletionPolicy %q", logicalID, resource.Type, resource.DeletionPolicy)
+				}
+				if resource.Type == "Custom::S3AutoDeleteObjects" {
+					t.Errorf("%s empties a bucket on deletion", logicalID)
+				}
+			}
+		})
+	}
+
+	t.Run("enables deletion protection", func(_ *testing.T) {
+		templates["single"].HasResourceProperties(jsii.String("AWS::RDS::DBCluster"), map[string]interface{}{
+			"DeletionProtection": true,
+		})
+		templates["single"].HasResourceProperties(jsii.String("AWS::Cognito::UserPool"), map[string]interface{}{
+			"DeletionProtection": "ACTIVE",
+		})
+	})
+
+	t.Run("does not empty the ECR repository", func(_ *testing.T) {
+		templates["single"].HasResourceProperties(jsii.String("AWS::ECR::Repository"), map[string]interface{}{
+			"EmptyOnDelete": false,
+		})
+	})
+}
+
+// statefulDeletionPolicies lists, per stateful resource type, the deletion policies that keep its data.
+var statefulDeletionPolicies = map[string]map[string]bool{
+	"AWS::RDS::DBCluster":         {"Snapshot": true, "Retain": true},
+	"AWS::S3::Bucket":             {"Retain": true},
+	"AWS::Cognito::UserPool":      {"Retain": true},
+	"AWS::SecretsManager::Secret": {"Retain": true},
+	"AWS::ECR::Repository":        {"Retain": true},
+}
+
+// templateResource is the part of a synthesized resource the tests inspect.
+type templateResource struct {
+	Type           string `json:"Type"`
+	DeletionPolicy string `json:"DeletionPolicy"`
+}
+
+// templateResources decodes the resources of a synthesized template by logical ID.
+func templateResources(template assertions.Template) map[string]templateResource {
+	var content struct {
+		Resources map[string]templateResource `json:"Resources"`
+	}
+	encoded, _ := json.Marshal(template.ToJSON())
+	_ = json.Unmarshal(encoded, &content)
+	return content.Resources
+}
@@ -0,0 +1,124 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// FlowLogDestination selects where VPC flow logs are delivered.
+type FlowLogDestination string
+
+const (
+	// FlowLogsCloudWatch delivers flow logs to a CloudWatch Logs log group, which suits ad hoc queries with
+	// Logs Insights.
+	FlowLogsCloudWatch FlowLogDestination = "cloudwatch-logs"
+
+	// FlowLogsS3 delivers flow logs to an S3 bucket as Parquet files in Hive-compatible hourly partitions, ready
+	// to be queried with Athena.
+	FlowLogsS3 FlowLogDestination = "s3"
+)
+
+// flowLogPrefix is the S3 key prefix flow logs are written under.
+const flowLogPrefix = "vpc-flow-logs/"
+
+// flowLogFormat lists the flow log record fields. Besides the default fields it records the packet-level
+// addresses, which differ from srcaddr and dstaddr behind a NAT or load balancer, the TCP flags, and the ECS
+// service and task the interface belongs to.
+func flowLogFormat() *[]awsec2.LogFormat {
+	return &[]awsec2.LogFormat{
+		awsec2.LogFormat_VERSION(),
+		awsec2.LogFormat_ACCOUNT_ID(),
+		awsec2.LogFormat_VPC_ID(),
+		awsec2.LogFormat_SUBNET_ID(),
+		awsec2.LogFormat_INTERFACE_ID(),
+		awsec2.LogFormat_SRC_ADDR(),
+		awsec2.LogFormat_DST_ADDR(),
+		awsec2.LogFormat_PKT_SRC_ADDR(),
+		awsec2.LogFormat_PKT_DST_ADDR(),
+		awsec2.LogFormat_SRC_PORT(),
+		awsec2.LogFormat_DST_PORT(),
+		awsec2.LogFormat_PROTOCOL(),
+		awsec2.LogFormat_TCP_FLAGS(),
+		awsec2.LogFormat_PACKETS(),
+		awsec2.LogFormat_BYTES(),
+		awsec2.LogFormat_START_TIMESTAMP(),
+		awsec2.LogFormat_END_TIMESTAMP(),
+		awsec2.LogFormat_ACTION(),
+		awsec2.LogFormat_FLOW_DIRECTION(),
+		awsec2.LogFormat_TRAFFIC_PATH(),
+		awsec2.LogFormat_ECS_SERVICE_NAME(),
+		awsec2.LogFormat_ECS_TASK_ID(),
+		awsec2.LogFormat_LOG_STATUS(),
+	}
+}
+
+// createFlowLogs records all traffic of the VPC to the given destination, keeping records as long as the
+// environment's log retention.
+func (n *Network) createFlowLogs(destination FlowLogDestination, naming *Naming, environment *EnvironmentConfig) {
+	var flowLogDestination awsec2.FlowLogDestination
+	switch destination {
+	case FlowLogsCloudWatch:
+		logGroup := awslogs.NewLogGroup(n.Construct, jsii.String("FlowLogGroup"), &awslogs.LogGroupProps{
+			LogGroupName:  jsii.String(naming.LogGroupName("vpc-flow-logs")),
+			Retention:     environment.LogRetention,
+			RemovalPolicy: environment.RemovalPolicy,
+		})
+		flowLogDestination = awsec2.FlowLogDestination_ToCloudWatchLogs(logGroup, nil)
+	case FlowLogsS3:
+		bucket := awss3.NewBucket(n.Construct, jsii.String("FlowLogBucket"), &awss3.BucketProps{
+			Encryption:        awss3.BucketEncryption_S3_MANAGED,
+			BlockPublicAccess: awss3.BlockPublicAccess_BLOCK_ALL(),
+			EnforceSSL:        jsii.Bool(true),
+			LifecycleRules:    flowLogLifecycle(environment),
+			RemovalPolicy:     environment.statefulRemovalPolicy(),
+			AutoDeleteObjects: jsii.Bool(environment.autoDeleteOnRemoval()),
+		})
+		SuppressSecurityRule(bucket, SecurityRuleMissingAccessLogs, "This bucket only receives VPC flow logs, which are themselves access logs.")
+		flowLogDestination = awsec2.FlowLogDestination_ToS3(bucket, jsii.String(flowLogPrefix), &awsec2.S3DestinationOptions{
+			FileFormat:               awsec2.FlowLogFileFormat_PARQUET,
+			HiveCompatiblePartitions: jsii.Bool(true),
+			PerHourPartition:         jsii.Bool(true),
+		})
+	default:
+		_, err := ParseFlowLogDestination(string(destination))
+		awscdk.Annotations_Of(n.Construct).AddError(jsii.String(err.Error()))
+		return
+	}
+
+	n.FlowLog = awsec2.NewFlowLog(n.Construct, jsii.String("FlowLog"), &awsec2.FlowLogProps{
+		ResourceType:           awsec2.FlowLogResourceType_FromVpc(n.Vpc),
+		Destination:            flowLogDestination,
+		TrafficType:            awsec2.FlowLogTrafficType_ALL,
+		LogFormat:              flowLogFormat(),
+		MaxAggregationInterval: awsec2.FlowLogMaxAggregationInterval_ONE_MINUTE,
+	})
+}
+
+// ParseFlowLogDestination returns the flow log destination with the given name. An empty name disables flow logs.
+func ParseFlowLogDestination(name string) (FlowLogDestination, error) {
+	switch destination := FlowLogDestination(name); destination {
+	case "", FlowLogsCloudWatch, FlowLogsS3:
+		return destination, nil
+	default:
+		return "", fmt.Errorf("unknown flow log destination %q", name)
+	}
+}
+
+// flowLogLifecycle expires flow log objects after the environment's log retention. Infinite retention keeps
+// them forever.
+func flowLogLifecycle(environment *EnvironmentConfig) *[]*awss3.LifecycleRule {
+	days, ok := environment.logRetentionInDays()
+	if !ok {
+		return nil
+	}
+	return &[]*awss3.LifecycleRule{{
+		Id:         jsii.String("ExpireFlowLogs"),
+		Prefix:     jsii.String(flowLogPrefix),
+		Expiration: awscdk.Duration_Days(jsii.Number(float64(days))),
+	}}
+}
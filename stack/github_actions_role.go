@@ -0,0 +1,160 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// GitHubActionsRoleProps defines the properties for the GitHubActionsRole construct.
+type GitHubActionsRoleProps struct {
+	// Frontend is the hosting the workflows upload to and invalidate. Required.
+	Frontend *SpaHosting
+
+	// Repositories are the "owner/name" repositories allowed to assume the role. Defaults to GitHubRepositories.
+	Repositories []string
+
+	// Environment supplies the removal policy. Defaults to DevEnvironment.
+	Environment *EnvironmentConfig
+
+	// Naming derives the role name and the parameter and secret paths it may read. Defaults to DefaultNamePrefix.
+	Naming *Naming
+}
+
+// GitHubActionsRole is assumed through the account's GitHub OIDC provider, which must already exist.
+type GitHubActionsRole struct {
+	constructs.Construct
+
+	// Role may push images to ECR, deploy the frontend, invalidate CloudFront and read the app configuration.
+	Role awsiam.IRole
+}
+
+// NewGitHubActionsRole creates the IAM role for GitHub Actions to push to ECR and deploy the frontend.
+func NewGitHubActionsRole(scope constructs.Construct, id string, props *GitHubActionsRoleProps) *GitHubActionsRole {
+	this := constructs.NewConstruct(scope, &id)
+	stack := awscdk.Stack_Of(this)
+	region, account := *stack.Region(), *stack.Account()
+	environment := environmentOrDefault(props.Environment)
+	naming := namingOrDefault(props.Naming)
+
+	repositories := props.Repositories
+	if len(repositories) == 0 {
+		repositories = GitHubRepositories
+	}
+	subjects := make([]interface{}, len(repositories))
+	for i, repository := range repositories {
+		subjects[i] = fmt.Sprintf("repo:%s:*", repository)
+	}
+
+	role := awsiam.NewRole(this, jsii.String("GitHubActionsRole"), &awsiam.RoleProps{
+		RoleName: jsii.String(naming.RoleName("GitHubActions-Role")),
+		AssumedBy: awsiam.NewWebIdentityPrincipal(
+			jsii.String(fmt.Sprintf("arn:aws:iam::%s:oidc-provider/token.actions.githubusercontent.com", account)),
+			&map[string]interface{}{
+				"StringEquals": map[string]interface{}{
+					"token.actions.githubusercontent.com:aud": "sts.amazonaws.com",
+				},
+				"StringLike": map[string]interface{}{
+					"token.actions.githubusercontent.com:sub": subjects,
+				},
+			},
+		),
+		InlinePolicies: &map[string]awsiam.PolicyDocument{
+			"ECRAccessPolicy": awsiam.NewPolicyDocument(&awsiam.PolicyDocumentProps{
+				Statements: &[]awsiam.PolicyStatement{
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Actions: &[]*string{
+							jsii.String("ecr:GetAuthorizationToken"),
+						},
+						Resources: &[]*string{
+							jsii.String("*"), // GetAuthorizationToken has no resource-level permissions
+						},
+					}),
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Actions: &[]*string{
+							jsii.String("ecr:BatchCheckLayerAvailability"),
+							jsii.String("ecr:GetDownloadUrlForLayer"),
+							jsii.String("ecr:BatchGetImage"),
+							jsii.String("ecr:PutImage"),
+							jsii.String("ecr:InitiateLayerUpload"),
+							jsii.String("ecr:UploadLayerPart"),
+							jsii.String("ecr:CompleteLayerUpload"),
+						},
+						Resources: &[]*string{
+							jsii.String(fmt.Sprintf("arn:aws:ecr:%s:%s:repository/%s", region, account, naming.Name("ecr-repo"))),
+						},
+					}),
+				},
+			}),
+			"S3FrontendDeployPolicy": awsiam.NewPolicyDocument(&awsiam.PolicyDocumentProps{
+				Statements: &[]awsiam.PolicyStatement{
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Actions: &[]*string{
+							jsii.String("s3:GetObject"),
+							jsii.String("s3:PutObject"),
+							jsii.String("s3:DeleteObject"),
+							jsii.String("s3:ListBucket"),
+							jsii.String("s3:GetBucketLocation"),
+						},
+						Resources: &[]*string{
+							props.Frontend.Bucket.BucketArn(),
+							jsii.String(fmt.Sprintf("%s/*", *props.Frontend.Bucket.BucketArn())),
+						},
+					}),
+				},
+			}),
+			"CloudFrontInvalidationPolicy": awsiam.NewPolicyDocument(&awsiam.PolicyDocumentProps{
+				Statements: &[]awsiam.PolicyStatement{
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Actions: &[]*string{
+							jsii.String("cloudfront:CreateInvalidation"),
+							jsii.String("cloudfront:GetInvalidation"),
+							jsii.String("cloudfront:ListInvalidations"),
+						},
+						Resources: &[]*string{
+							jsii.String(fmt.Sprintf("arn:aws:cloudfront::%s:distribution/%s", account, props.Frontend.DistributionID)),
+						},
+					}),
+				},
+			}),
+			"ParameterStoreAccessPolicy": awsiam.NewPolicyDocument(&awsiam.PolicyDocumentProps{
+				Statements: &[]awsiam.PolicyStatement{
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Actions: &[]*string{
+							jsii.String("ssm:GetParameter"),
+							jsii.String("ssm:GetParameters"),
+							jsii.String("ssm:GetParametersByPath"),
+						},
+						Resources: &[]*string{
+							jsii.String(fmt.Sprintf("arn:aws:ssm:%s:%s:parameter%s/*", region, account, naming.ParameterPath())),
+						},
+					}),
+				},
+			}),
+			"SecretsManagerAccessPolicy": awsiam.NewPolicyDocument(&awsiam.PolicyDocumentProps{
+				Statements: &[]awsiam.PolicyStatement{
+					awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+						Actions: &[]*string{
+							jsii.String("secretsmanager:GetSecretValue"),
+							jsii.String("secretsmanager:DescribeSecret"),
+						},
+						Resources: &[]*string{
+							jsii.String(fmt.Sprintf("arn:aws:secretsmanager:%s:%s:secret:%s/*", region, account, naming.ParameterPath())),
+						},
+					}),
+				},
+			}),
+		},
+	})
+
+	// Apply removal policy for clean deletion
+	role.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	return &GitHubActionsRole{
+		Construct: this,
+		Role:      role,
+	}
+}
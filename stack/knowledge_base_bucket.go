@@ -0,0 +1,57 @@
+package stack
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// KnowledgeBaseBucketProps defines the properties for the KnowledgeBaseBucket construct.
+type KnowledgeBaseBucketProps struct {
+	// Environment supplies the removal policy. Defaults to DevEnvironment.
+	Environment *EnvironmentConfig
+
+	// Naming derives the bucket name. Defaults to DefaultNamePrefix.
+	Naming *Naming
+
+	// AccessLogs receives the bucket's server access logs under "s3/knowledge-base/". Leave nil to disable them.
+	AccessLogs awss3.IBucket
+}
+
+// KnowledgeBaseBucket is the versioned, private bucket holding the documents indexed by the Bedrock knowledge base.
+type KnowledgeBaseBucket struct {
+	constructs.Construct
+
+	// Bucket is the knowledge base source bucket.
+	Bucket awss3.IBucket
+	// BucketName is "<prefix>-bucket-<account>-<region>".
+	BucketName string
+}
+
+// NewKnowledgeBaseBucket creates the knowledge base source bucket.
+func NewKnowledgeBaseBucket(scope constructs.Construct, id string, props *KnowledgeBaseBucketProps) *KnowledgeBaseBucket {
+	this := constructs.NewConstruct(scope, &id)
+	stack := awscdk.Stack_Of(this)
+	environment := environmentOrDefault(props.Environment)
+
+	bucketName := namingOrDefault(props.Naming).Name("bucket", *stack.Account(), *stack.Region())
+	bucket := awss3.NewBucket(this, jsii.String("CodeRefactorBucket"), &awss3.BucketProps{
+		BucketName:        jsii.String(bucketName),
+		RemovalPolicy:     environment.statefulRemovalPolicy(),
+		AutoDeleteObjects: jsii.Bool(environment.autoDeleteOnRemoval()),
+		Versioned:         jsii.Bool(true),
+		BlockPublicAccess: awss3.BlockPublicAccess_BLOCK_ALL(),
+		Encryption:        awss3.BucketEncryption_S3_MANAGED,
+		EnforceSSL:        jsii.Bool(true),
+
+		ServerAccessLogsBucket: props.AccessLogs,
+		ServerAccessLogsPrefix: accessLogPrefix(props.AccessLogs, "s3/knowledge-base/"),
+	})
+
+	return &KnowledgeBaseBucket{
+		Construct:  this,
+		Bucket:     bucket,
+		BucketName: bucketName,
+	}
+}
@@ -0,0 +1,120 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscertificatemanager"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awselasticloadbalancingv2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsroute53"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsroute53targets"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// HTTPSConfig serves the backend load balancer over TLS. API Gateway then calls it by DomainName over HTTPS and
+// plain HTTP requests are redirected.
+type HTTPSConfig struct {
+	// DomainName is the name API Gateway calls the load balancer by, which the certificate must cover. Required.
+	DomainName string
+
+	// HostedZone is the public zone that validates a requested certificate through DNS and, for an
+	// internet-facing load balancer, receives an alias record for DomainName. Required unless CertificateArn is
+	// set.
+	HostedZone *HostedZoneConfig
+
+	// CertificateArn imports an existing ACM certificate in the stack's region instead of requesting one.
+	CertificateArn string
+}
+
+// HostedZoneConfig identifies an existing public Route 53 hosted zone without a lookup.
+type HostedZoneConfig struct {
+	// ID is the hosted zone ID, such as Z0123456789ABCDEFGHIJ. Required.
+	ID string
+
+	// Name is the zone's domain name, such as example.com. Required.
+	Name string
+}
+
+// Listener ports of the backend load balancer.
+const (
+	httpPort  = 80
+	httpsPort = 443
+)
+
+// listenerPort is the port the load balancer serves the API on.
+func (l LoadBalancerConfig) listenerPort() float64 {
+	if l.HTTPS != nil {
+		return httpsPort
+	}
+	return httpPort
+}
+
+// listenerProtocol is the protocol the load balancer serves the API with.
+func (l LoadBalancerConfig) listenerProtocol() awselasticloadbalancingv2.ApplicationProtocol {
+	if l.HTTPS != nil {
+		return awselasticloadbalancingv2.ApplicationProtocol_HTTPS
+	}
+	return awselasticloadbalancingv2.ApplicationProtocol_HTTP
+}
+
+// addHTTPS turns the listener properties into an HTTPS listener with the configured certificate and the
+// recommended TLS 1.3 policy, which still accepts TLS 1.2 clients such as API Gateway. Port 80 only redirects to
+// it.
+func (s *RefactorService) addHTTPS(https *HTTPSConfig, listenerProps *awselasticloadbalancingv2.BaseApplicationListenerProps, open bool, environment *EnvironmentConfig) {
+	var zone awsroute53.IHostedZone
+	if https.HostedZone != nil {
+		zone = awsroute53.HostedZone_FromHostedZoneAttributes(s.Construct, jsii.String("HostedZone"), &awsroute53.HostedZoneAttributes{
+			HostedZoneId: jsii.String(https.HostedZone.ID),
+			ZoneName:     jsii.String(https.HostedZone.Name),
+		})
+	}
+
+	if certificate := s.loadBalancerCertificate(https, zone, environment); certificate != nil {
+		listenerProps.Certificates = &[]awselasticloadbalancingv2.IListenerCertificate{
+			awselasticloadbalancingv2.ListenerCertificate_FromCertificateManager(certificate),
+		}
+	}
+	listenerProps.SslPolicy = awselasticloadbalancingv2.SslPolicy_RECOMMENDED_TLS
+
+	s.LoadBalancer.AddListener(jsii.String("HttpRedirectListener"), &awselasticloadbalancingv2.BaseApplicationListenerProps{
+		Port:     jsii.Number(httpPort),
+		Protocol: awselasticloadbalancingv2.ApplicationProtocol_HTTP,
+		Open:     jsii.Bool(open),
+		DefaultAction: awselasticloadbalancingv2.ListenerAction_Redirect(&awselasticloadbalancingv2.RedirectOptions{
+			Protocol:  jsii.String(string(awselasticloadbalancingv2.ApplicationProtocol_HTTPS)),
+			Port:      jsii.String(fmt.Sprint(httpsPort)),
+			Permanent: jsii.Bool(true),
+		}),
+	})
+
+	// An internal load balancer is only reached through the VPC link, which does not resolve DomainName
+	if open && zone != nil {
+		record := awsroute53.NewARecord(s.Construct, jsii.String("LoadBalancerAlias"), &awsroute53.ARecordProps{
+			Zone:       zone,
+			RecordName: jsii.String(https.DomainName),
+			Target:     awsroute53.RecordTarget_FromAlias(awsroute53targets.NewLoadBalancerTarget(s.LoadBalancer, nil)),
+		})
+		record.ApplyRemovalPolicy(environment.RemovalPolicy)
+	}
+
+	s.DomainName = https.DomainName
+}
+
+// loadBalancerCertificate imports the configured certificate, or requests one for DomainName validated through
+// the hosted zone.
+func (s *RefactorService) loadBalancerCertificate(https *HTTPSConfig, zone awsroute53.IHostedZone, environment *EnvironmentConfig) awscertificatemanager.ICertificate {
+	if https.CertificateArn != "" {
+		return awscertificatemanager.Certificate_FromCertificateArn(s.Construct, jsii.String("LoadBalancerCertificate"), jsii.String(https.CertificateArn))
+	}
+	if zone == nil {
+		awscdk.Annotations_Of(s.Construct).AddError(jsii.String("HTTPS needs a hosted zone to validate the certificate, or an existing certificate ARN"))
+		return nil
+	}
+
+	certificate := awscertificatemanager.NewCertificate(s.Construct, jsii.String("LoadBalancerCertificate"), &awscertificatemanager.CertificateProps{
+		DomainName: jsii.String(https.DomainName),
+		Validation: awscertificatemanager.CertificateValidation_FromDns(zone),
+	})
+	certificate.ApplyRemovalPolicy(environment.RemovalPolicy)
+	return certificate
+}
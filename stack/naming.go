@@ -0,0 +1,66 @@
+package stack
+
+import (
+	"strings"
+)
+
+// DefaultNamePrefix is the prefix used for physical names when no Naming is given.
+const DefaultNamePrefix = "code-refactor"
+
+// Naming derives physical resource names, export names and parameter paths from a single prefix
+// so that several copies of the stack can live side by side in one account.
+type Naming struct {
+	// Prefix is the lower-case, hyphen separated prefix, e.g. "code-refactor" or "code-refactor-staging".
+	Prefix string
+}
+
+// NewNaming creates a naming strategy for the given prefix.
+func NewNaming(prefix string) *Naming {
+	return &Naming{Prefix: prefix}
+}
+
+// Name returns a hyphen separated physical name, e.g. "code-refactor-db-secret".
+func (n *Naming) Name(parts ...string) string {
+	return strings.Join(append([]string{n.Prefix}, parts...), "-")
+}
+
+// ExportName returns a CloudFormation export name, e.g. "CodeRefactor-ECR-Repository-URI".
+func (n *Naming) ExportName(suffix string) string {
+	return n.pascalPrefix() + "-" + suffix
+}
+
+// RoleName returns an IAM role name, e.g. "CodeRefactor-GitHubActions-Role".
+func (n *Naming) RoleName(suffix string) string {
+	return n.pascalPrefix() + "-" + suffix
+}
+
+// ParameterPath returns a slash separated path rooted at the prefix, e.g. "/code-refactor/backend/secrets".
+// Without parts it returns the root path itself.
+func (n *Naming) ParameterPath(parts ...string) string {
+	return "/" + strings.Join(append([]string{n.Prefix}, parts...), "/")
+}
+
+// LogGroupName returns the CloudWatch log group name for a service, e.g. "/ecs/code-refactor".
+func (n *Naming) LogGroupName(service string) string {
+	return "/" + service + "/" + n.Prefix
+}
+
+// pascalPrefix converts the prefix to PascalCase, e.g. "code-refactor" becomes "CodeRefactor".
+func (n *Naming) pascalPrefix() string {
+	var builder strings.Builder
+	for _, part := range strings.Split(n.Prefix, "-") {
+		if part == "" {
+			continue
+		}
+		builder.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return builder.String()
+}
+
+// namingOrDefault returns the given naming, or one using DefaultNamePrefix when it is nil.
+func namingOrDefault(naming *Naming) *Naming {
+	if naming == nil {
+		return NewNaming(DefaultNamePrefix)
+	}
+	return naming
+}
@@ -0,0 +1,123 @@
+package stack
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestNaming(t *testing.T) {
+	// Arrange
+	naming := NewNaming("code-refactor-staging")
+
+	t.Run("derives physical names", func(t *testing.T) {
+		if got := naming.Name("db-secret"); got != "code-refactor-staging-db-secret" {
+			t.Errorf("Name() = %q", got)
+		}
+	})
+
+	t.Run("derives export and role names in PascalCase", func(t *testing.T) {
+		if got := naming.ExportName("ECR-Repository-URI"); got != "CodeRefactorStaging-ECR-Repository-URI" {
+			t.Errorf("ExportName() = %q", got)
+		}
+		if got := naming.RoleName("GitHubActions-Role"); got != "CodeRefactorStaging-GitHubActions-Role" {
+			t.Errorf("RoleName() = %q", got)
+		}
+	})
+
+	t.Run("derives parameter paths and log groups", func(t *testing.T) {
+		if got := naming.ParameterPath(); got != "/code-refactor-staging" {
+			t.Errorf("ParameterPath() = %q", got)
+		}
+		if got := naming.ParameterPath("backend", "secrets"); got != "/code-refactor-staging/backend/secrets" {
+			t.Errorf("ParameterPath(backend, secrets) = %q", got)
+		}
+		if got := naming.LogGroupName("ecs"); got != "/ecs/code-refactor-staging" {
+			t.Errorf("LogGroupName() = %q", got)
+		}
+	})
+}
+
+func TestAppStack_PrefixedStacksCoexist(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	newStack := func(id, prefix string) *AppStack {
+		return NewAppStack(app, id, &AppStackProps{
+			StackProps: awscdk.StackProps{
+				Env: &awscdk.Environment{
+					Region: jsii.String("us-east-1"),
+				},
+			},
+			Naming: NewNaming(prefix),
+		})
+	}
+	first := newStack("AliceStack", "code-refactor-alice")
+	second := newStack("BobStack", "code-refactor-bob")
+
+	// Act
+	app.Synth(nil)
+	firstNames := physicalNames(t, assertions.Template_FromStack(first.Stack, nil))
+	secondNames := physicalNames(t, assertions.Template_FromStack(second.Stack, nil))
+
+	// Assert
+	if len(firstNames) == 0 {
+		t.Fatal("expected physical names in the synthesized template")
+	}
+	for name := range firstNames {
+		if secondNames[name] {
+			t.Errorf("name %s is used by both stacks", name)
+		}
+	}
+}
+
+// physicalNames collects every explicit physical name and export name in a template.
+func physicalNames(t *testing.T, template assertions.Template) map[string]bool {
+	t.Helper()
+
+	nameProperties := []string{
+		"BucketName",
+		"DBClusterIdentifier",
+		"Domain",
+		"LogGroupName",
+		"Name",
+		"RepositoryName",
+		"RoleName",
+		"UserPoolName",
+	}
+
+	names := map[string]bool{}
+	add := func(value interface{}) {
+		if reference, ok := value.(map[string]interface{}); ok && reference["Ref"] != nil {
+			// References point at another resource of the same template and are not names.
+			return
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			t.Fatalf("failed to encode %v: %v", value, err)
+		}
+		names[string(encoded)] = true
+	}
+
+	content := *template.ToJSON()
+	resources, _ := content["Resources"].(map[string]interface{})
+	for _, resource := range resources {
+		properties, _ := resource.(map[string]interface{})["Properties"].(map[string]interface{})
+		for _, property := range nameProperties {
+			if value, ok := properties[property]; ok {
+				add(value)
+			}
+		}
+	}
+
+	outputs, _ := content["Outputs"].(map[string]interface{})
+	for _, output := range outputs {
+		if export, ok := output.(map[string]interface{})["Export"].(map[string]interface{}); ok {
+			add(export["Name"])
+		}
+	}
+
+	return names
+}
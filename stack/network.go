@@ -0,0 +1,298 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// NetworkEgress selects the subnets workloads run in and how they reach the internet.
+type NetworkEgress string
+
+const (
+	// NetworkEgressPublic places workloads in the public subnets. It needs no NAT and is the default. Fargate tasks
+	// get public IPs, but Lambda functions never do, so the migration Lambda reaches Secrets Manager through an
+	// interface endpoint.
+	NetworkEgressPublic NetworkEgress = "public"
+
+	// NetworkEgressNATGateway places workloads in private subnets behind a managed NAT gateway in every AZ.
+	NetworkEgressNATGateway NetworkEgress = "nat-gateway"
+
+	// NetworkEgressNATInstance places workloads in private subnets behind a single NAT instance, which costs less
+	// than NAT gateways but is a single point of failure.
+	NetworkEgressNATInstance NetworkEgress = "nat-instance"
+
+	// NetworkEgressNone places workloads in private subnets without any route to the internet.
+	NetworkEgressNone NetworkEgress = "none"
+)
+
+// Subnet group names. Each group has one subnet per availability zone.
+const (
+	publicSubnetGroup   = "Public"
+	privateSubnetGroup  = "Private"
+	databaseSubnetGroup = "Database"
+)
+
+// NetworkConfig holds the VPC layout settings.
+type NetworkConfig struct {
+	// Egress selects the workload subnets and their route to the internet. Defaults to NetworkEgressPublic.
+	// Ignored for an existing VPC.
+	Egress NetworkEgress
+
+	// ExistingVpc places the stack in a centrally managed VPC instead of creating one.
+	ExistingVpc *ExistingVpcConfig
+
+	// VpcEndpoints adds gateway and interface endpoints so workloads reach the AWS services they use without
+	// leaving the VPC. Required for NetworkEgressNone.
+	VpcEndpoints bool
+
+	// FlowLogs records the VPC's traffic to the given destination. Empty disables flow logs.
+	FlowLogs FlowLogDestination
+
+	// DualStack adds an Amazon provided IPv6 CIDR to the VPC and an IPv6 CIDR to every subnet. Private subnets
+	// with egress reach the internet over IPv6 through an egress-only internet gateway. Not supported for an
+	// existing VPC.
+	DualStack bool
+}
+
+// ExistingVpcConfig identifies an existing VPC and the subnet groups the stack uses in it. Subnet groups are
+// the "aws-cdk:subnet-name" tag values of a looked up VPC, or the subnet names given in Attributes.
+type ExistingVpcConfig struct {
+	// VpcID is looked up at synthesis time, which needs the stack's account and region. Ignored when Attributes
+	// is set.
+	VpcID string
+
+	// Attributes describe the VPC and its subnets so that no lookup is needed.
+	Attributes *awsec2.VpcAttributes
+
+	// WorkloadSubnetGroup names the subnets the Fargate tasks and Lambda functions run in. Defaults to "Private".
+	WorkloadSubnetGroup string
+
+	// DatabaseSubnetGroup names the isolated subnets Aurora runs in. Defaults to "Database".
+	DatabaseSubnetGroup string
+}
+
+// NetworkProps defines the properties for the Network construct.
+type NetworkProps struct {
+	NetworkConfig
+
+	// Environment supplies the removal policy. Defaults to DevEnvironment.
+	Environment *EnvironmentConfig
+
+	// Naming scopes the endpoint policies to the stack's resources and names the flow log group. Defaults to
+	// DefaultNamePrefix.
+	Naming *Naming
+}
+
+// Network is the VPC that the database, migration Lambda and containers run in.
+type Network struct {
+	constructs.Construct
+
+	// Vpc spans two availability zones with a public and an isolated database subnet in each, plus a private
+	// subnet in each unless egress is NetworkEgressPublic. It is imported when ExistingVpc is set.
+	Vpc awsec2.IVpc
+	// Egress is the egress mode the VPC was built for.
+	Egress NetworkEgress
+	// WorkloadSubnets selects the subnets the Fargate tasks and Lambda functions run in.
+	WorkloadSubnets *awsec2.SubnetSelection
+	// DatabaseSubnets selects the isolated subnets dedicated to Aurora, which have no route to the internet.
+	DatabaseSubnets *awsec2.SubnetSelection
+	// Endpoints are the VPC endpoints. Without VpcEndpoints, a VPC the stack creates without NAT only has the
+	// Secrets Manager endpoint the migration Lambda needs.
+	Endpoints []awsec2.IVpcEndpoint
+	// FlowLog records the VPC's traffic, nil unless FlowLogs is set.
+	FlowLog awsec2.FlowLog
+	// DualStack reports whether the VPC and its subnets have IPv6 CIDRs.
+	DualStack bool
+
+	// ipv6CidrBlock is the VPC's IPv6 CIDR, nil unless DualStack is set.
+	ipv6CidrBlock *string
+}
+
+// NewNetwork creates the VPC for RDS and Fargate, or imports the existing VPC named in ExistingVpc.
+func NewNetwork(scope constructs.Construct, id string, props *NetworkProps) *Network {
+	this := constructs.NewConstruct(scope, &id)
+	environment := environmentOrDefault(props.Environment)
+	egress, err := ParseNetworkEgress(string(props.Egress))
+	if err != nil {
+		awscdk.Annotations_Of(this).AddError(jsii.String(err.Error()))
+	}
+
+	network := &Network{
+		Construct: this,
+		Egress:    egress,
+		DatabaseSubnets: &awsec2.SubnetSelection{
+			SubnetGroupName: jsii.String(databaseSubnetGroup),
+		},
+	}
+	if props.ExistingVpc != nil {
+		if props.DualStack {
+			awscdk.Annotations_Of(this).AddError(jsii.String("DualStack is not supported for an existing VPC"))
+		}
+		network.importVpc(props.ExistingVpc)
+	} else {
+		network.DualStack = props.DualStack
+		network.createVpc(environment)
+	}
+
+	if props.FlowLogs != "" {
+		network.createFlowLogs(props.FlowLogs, namingOrDefault(props.Naming), environment)
+	}
+
+	switch {
+	case props.VpcEndpoints:
+		network.createEndpoints(namingOrDefault(props.Naming), environment)
+	case props.ExistingVpc != nil:
+		// Egress is up to the owners of an existing VPC
+	case egress == NetworkEgressNone:
+		network.createSecretsManagerEndpoint(namingOrDefault(props.Naming), environment)
+		awscdk.Annotations_Of(this).AddWarningV2(jsii.String("network:no-endpoints"),
+			jsii.String("Workloads without egress cannot reach ECR or CloudWatch Logs unless VpcEndpoints is set"))
+	case egress == NetworkEgressPublic:
+		network.createSecretsManagerEndpoint(namingOrDefault(props.Naming), environment)
+	}
+
+	return network
+}
+
+// createVpc creates a VPC with public, workload and database subnets in two availability zones.
+func (n *Network) createVpc(environment *EnvironmentConfig) {
+	subnets := []*awsec2.SubnetConfiguration{
+		{
+			CidrMask:   jsii.Number(24),
+			Name:       jsii.String(publicSubnetGroup),
+			SubnetType: awsec2.SubnetType_PUBLIC,
+		},
+	}
+	workloadSubnetGroup := publicSubnetGroup
+	if workloadSubnetType := n.Egress.workloadSubnetType(); workloadSubnetType != awsec2.SubnetType_PUBLIC {
+		workloadSubnetGroup = privateSubnetGroup
+		subnets = append(subnets, &awsec2.SubnetConfiguration{
+			CidrMask:   jsii.Number(24),
+			Name:       jsii.String(privateSubnetGroup),
+			SubnetType: workloadSubnetType,
+		})
+	}
+	subnets = append(subnets, &awsec2.SubnetConfiguration{
+		CidrMask:   jsii.Number(24),
+		Name:       jsii.String(databaseSubnetGroup),
+		SubnetType: awsec2.SubnetType_PRIVATE_ISOLATED,
+	})
+
+	vpcProps := &awsec2.VpcProps{
+		MaxAzs:              jsii.Number(2),
+		NatGateways:         jsii.Number(0),
+		SubnetConfiguration: &subnets,
+	}
+	if n.DualStack {
+		vpcProps.IpProtocol = awsec2.IpProtocol_DUAL_STACK
+	}
+	var natInstance awsec2.NatInstanceProviderV2
+	switch n.Egress {
+	case NetworkEgressNATGateway:
+		vpcProps.NatGateways = jsii.Number(2)
+	case NetworkEgressNATInstance:
+		natInstance = awsec2.NatProvider_InstanceV2(&awsec2.NatInstanceProps{
+			InstanceType: awsec2.InstanceType_Of(awsec2.InstanceClass_T4G, awsec2.InstanceSize_NANO),
+			MachineImage: awsec2.MachineImage_LatestAmazonLinux2023(&awsec2.AmazonLinux2023ImageSsmParameterProps{
+				CpuType: awsec2.AmazonLinuxCpuType_ARM_64,
+			}),
+			DefaultAllowedTraffic: awsec2.NatTrafficDirection_OUTBOUND_ONLY,
+		})
+		vpcProps.NatGatewayProvider = natInstance
+		vpcProps.NatGateways = jsii.Number(1)
+	}
+
+	vpc := awsec2.NewVpc(n.Construct, jsii.String("RefactorVpc"), vpcProps)
+
+	// Only forward traffic originating inside the VPC through the NAT instance. IPv6 traffic bypasses it through
+	// the egress-only internet gateway, so it needs no IPv6 rule.
+	if natInstance != nil {
+		natInstance.Connections().AllowFrom(awsec2.Peer_Ipv4(vpc.VpcCidrBlock()), awsec2.Port_AllTraffic(), jsii.String("Allow traffic from the private subnets"))
+	}
+
+	// Apply removal policy to VPC for clean deletion
+	vpc.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	n.Vpc = vpc
+	if n.DualStack {
+		n.ipv6CidrBlock = awscdk.Fn_Select(jsii.Number(0), vpc.VpcIpv6CidrBlocks())
+	}
+	n.WorkloadSubnets = &awsec2.SubnetSelection{
+		SubnetGroupName: jsii.String(workloadSubnetGroup),
+	}
+}
+
+// importVpc references an existing VPC by its attributes, or looks it up by ID when no attributes are given.
+func (n *Network) importVpc(existing *ExistingVpcConfig) {
+	if existing.Attributes != nil {
+		n.Vpc = awsec2.Vpc_FromVpcAttributes(n.Construct, jsii.String("ExistingVpc"), existing.Attributes)
+	} else {
+		n.Vpc = awsec2.Vpc_FromLookup(n.Construct, jsii.String("ExistingVpc"), &awsec2.VpcLookupOptions{
+			VpcId: jsii.String(existing.VpcID),
+		})
+	}
+
+	workloadGroup, databaseGroup := existing.WorkloadSubnetGroup, existing.DatabaseSubnetGroup
+	if workloadGroup == "" {
+		workloadGroup = privateSubnetGroup
+	}
+	if databaseGroup == "" {
+		databaseGroup = databaseSubnetGroup
+	}
+	n.WorkloadSubnets = &awsec2.SubnetSelection{SubnetGroupName: jsii.String(workloadGroup)}
+	n.DatabaseSubnets = &awsec2.SubnetSelection{SubnetGroupName: jsii.String(databaseGroup)}
+}
+
+// DependOnEndpoints makes each workload depend on the VPC endpoints. CloudFormation then deletes the workloads,
+// and waits for their network interfaces to be released, before it deletes the endpoints they call through.
+func (n *Network) DependOnEndpoints(workloads ...constructs.IConstruct) {
+	for _, workload := range workloads {
+		for _, endpoint := range n.Endpoints {
+			workload.Node().AddDependency(endpoint)
+		}
+	}
+}
+
+// ParseNetworkEgress returns the egress mode with the given name. An empty name selects NetworkEgressPublic.
+func ParseNetworkEgress(name string) (NetworkEgress, error) {
+	switch egress := NetworkEgress(name); egress {
+	case "":
+		return NetworkEgressPublic, nil
+	case NetworkEgressPublic, NetworkEgressNATGateway, NetworkEgressNATInstance, NetworkEgressNone:
+		return egress, nil
+	default:
+		return NetworkEgressPublic, fmt.Errorf("unknown network egress %q", name)
+	}
+}
+
+// workloadSubnetType is the type of the subnets workloads run in.
+func (e NetworkEgress) workloadSubnetType() awsec2.SubnetType {
+	switch e {
+	case NetworkEgressNATGateway, NetworkEgressNATInstance:
+		return awsec2.SubnetType_PRIVATE_WITH_EGRESS
+	case NetworkEgressNone:
+		return awsec2.SubnetType_PRIVATE_ISOLATED
+	default:
+		return awsec2.SubnetType_PUBLIC
+	}
+}
+
+// networkingOrDefault returns the given network settings, or the zero value selecting the defaults when it is nil.
+func networkingOrDefault(networking *NetworkConfig) NetworkConfig {
+	if networking == nil {
+		return NetworkConfig{}
+	}
+	return *networking
+}
+
+// workloadSubnetsOrDefault returns the given subnet selection, or the public subnets when it is nil.
+func workloadSubnetsOrDefault(subnets *awsec2.SubnetSelection) *awsec2.SubnetSelection {
+	if subnets == nil {
+		return &awsec2.SubnetSelection{SubnetType: awsec2.SubnetType_PUBLIC}
+	}
+	return subnets
+}
@@ -0,0 +1,518 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestNetwork_Egress(t *testing.T) {
+	tests := []struct {
+		egress           NetworkEgress
+		wantSubnets      int
+		wantNATGateways  int
+		wantNATInstances int
+		wantNATRoutes    int
+		wantEndpoints    int
+	}{
+		{egress: "", wantSubnets: 4, wantEndpoints: 1},
+		{egress: NetworkEgressPublic, wantSubnets: 4, wantEndpoints: 1},
+		{egress: NetworkEgressNATGateway, wantSubnets: 6, wantNATGateways: 2, wantNATRoutes: 2},
+		{egress: NetworkEgressNATInstance, wantSubnets: 6, wantNATInstances: 1, wantNATRoutes: 2},
+		{egress: NetworkEgressNone, wantSubnets: 6, wantEndpoints: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.egress), func(_ *testing.T) {
+			// Arrange
+			app := awscdk.NewApp(nil)
+			stack := awscdk.NewStack(app, jsii.String("NetworkStack"), &awscdk.StackProps{
+				Env: &awscdk.Environment{
+					Account: jsii.String("123456789012"),
+					Region:  jsii.String("us-east-1"),
+				},
+			})
+
+			// Act
+			NewNetwork(stack, "Network", &NetworkProps{
+				NetworkConfig: NetworkConfig{Egress: tt.egress},
+			})
+			template := assertions.Template_FromStack(stack, nil)
+
+			// Assert
+			template.ResourceCountIs(jsii.String("AWS::EC2::Subnet"), jsii.Number(tt.wantSubnets))
+			template.ResourceCountIs(jsii.String("AWS::EC2::NatGateway"), jsii.Number(tt.wantNATGateways))
+			template.ResourceCountIs(jsii.String("AWS::EC2::Instance"), jsii.Number(tt.wantNATInstances))
+			routes := template.FindResources(jsii.String("AWS::EC2::Route"), map[string]interface{}{
+				"Properties": map[string]interface{}{
+					"DestinationCidrBlock": "0.0.0.0/0",
+					"GatewayId":            assertions.Match_Absent(),
+				},
+			})
+			if len(*routes) != tt.wantNATRoutes {
+				t.Errorf("found %d default routes through NAT, want %d", len(*routes), tt.wantNATRoutes)
+			}
+			// Without NAT the migration Lambda, which never has a public IP, reads its secret through an endpoint
+			template.ResourceCountIs(jsii.String("AWS::EC2::VPCEndpoint"), jsii.Number(tt.wantEndpoints))
+			if tt.wantEndpoints > 0 {
+				template.HasResourceProperties(jsii.String("AWS::EC2::VPCEndpoint"), map[string]interface{}{
+					"ServiceName":       "com.amazonaws.us-east-1.secretsmanager",
+					"PrivateDnsEnabled": true,
+				})
+			}
+		})
+	}
+}
+
+func TestNetwork_RejectsUnknownEgress(t *testing.T) {
+	if _, err := ParseNetworkEgress("internet"); err == nil {
+		t.Error("expected an error for an unknown egress mode")
+	}
+}
+
+func TestAppStack_PlacesWorkloadsInPrivateSubnets(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := NewAppStack(app, "TestStack", &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Region: jsii.String("us-east-1"),
+			},
+		},
+		Networking: &NetworkConfig{Egress: NetworkEgressNATGateway},
+	})
+
+	// Act
+	template := assertions.Template_FromStack(stack.Stack, nil)
+
+	// Assert
+	t.Run("tasks run without public IPs", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::ECS::Service"), map[string]interface{}{
+			"NetworkConfiguration": map[string]interface{}{
+				"AwsvpcConfiguration": map[string]interface{}{
+					"AssignPublicIp": "DISABLED",
+					"Subnets": []interface{}{
+						map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("PrivateSubnet1"))},
+						map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("PrivateSubnet2"))},
+					},
+				},
+			},
+		})
+	})
+
+	t.Run("migration Lambda runs in the private subnets", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::Lambda::Function"), map[string]interface{}{
+			"Handler": "handler.lambda_handler",
+			"VpcConfig": map[string]interface{}{
+				"SubnetIds": []interface{}{
+					map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("PrivateSubnet1"))},
+					map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("PrivateSubnet2"))},
+				},
+			},
+		})
+	})
+
+	t.Run("load balancer stays in the public subnets", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::LoadBalancer"), map[string]interface{}{
+			"Scheme": "internet-facing",
+			"Subnets": []interface{}{
+				map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("PublicSubnet1"))},
+				map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("PublicSubnet2"))},
+			},
+		})
+	})
+}
+
+func TestAppStack_IsolatesDatabaseSubnets(t *testing.T) {
+	for _, egress := range []NetworkEgress{NetworkEgressPublic, NetworkEgressNATGateway, NetworkEgressNATInstance, NetworkEgressNone} {
+		t.Run(string(egress), func(t *testing.T) {
+			// Arrange
+			app := awscdk.NewApp(nil)
+			stack := NewAppStack(app, "TestStack", &AppStackProps{
+				StackProps: awscdk.StackProps{
+					Env: &awscdk.Environment{
+						Region: jsii.String("us-east-1"),
+					},
+				},
+				Networking: &NetworkConfig{Egress: egress},
+			})
+
+			// Act
+			resources, _ := (*assertions.Template_FromStack(stack.Stack, nil).ToJSON())["Resources"].(map[string]interface{})
+
+			// Assert
+			routeTables := map[string]string{}
+			internetRoutes := map[string]bool{}
+			var databaseSubnets []interface{}
+			for _, resource := range resources {
+				resource, _ := resource.(map[string]interface{})
+				properties, _ := resource["Properties"].(map[string]interface{})
+				switch resource["Type"] {
+				case "AWS::RDS::DBSubnetGroup":
+					databaseSubnets, _ = properties["SubnetIds"].([]interface{})
+				case "AWS::EC2::SubnetRouteTableAssociation":
+					routeTables[reference(properties["SubnetId"])] = reference(properties["RouteTableId"])
+				case "AWS::EC2::Route":
+					if properties["DestinationCidrBlock"] == "0.0.0.0/0" || properties["DestinationIpv6CidrBlock"] == "::/0" {
+						internetRoutes[reference(properties["RouteTableId"])] = true
+					}
+				}
+			}
+
+			if len(databaseSubnets) == 0 {
+				t.Fatal("expected a DB subnet group with subnets")
+			}
+			for _, subnet := range databaseSubnets {
+				routeTable, ok := routeTables[reference(subnet)]
+				if !ok {
+					t.Errorf("database subnet %v has no route table", subnet)
+				}
+				if internetRoutes[routeTable] {
+					t.Errorf("database subnet %v routes to the internet through %s", subnet, routeTable)
+				}
+			}
+		})
+	}
+}
+
+// reference returns the logical ID of a {"Ref": ...} value, or an empty string.
+func reference(value interface{}) string {
+	ref, _ := value.(map[string]interface{})
+	id, _ := ref["Ref"].(string)
+	return id
+}
+
+func TestNetwork_VpcEndpoints(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := NewAppStack(app, "TestStack", &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Account: jsii.String("123456789012"),
+				Region:  jsii.String("us-east-1"),
+			},
+		},
+		Naming:     NewNaming("docs-site"),
+		Networking: &NetworkConfig{Egress: NetworkEgressNone, VpcEndpoints: true},
+	})
+
+	// Act
+	template := assertions.Template_FromStack(stack.Stack, nil)
+
+	// Assert
+	t.Run("creates gateway and interface endpoints", func(_ *testing.T) {
+		template.ResourceCountIs(jsii.String("AWS::EC2::VPCEndpoint"), jsii.Number(10))
+		template.HasResourceProperties(jsii.String("AWS::EC2::VPCEndpoint"), map[string]interface{}{
+			"ServiceName":       "com.amazonaws.us-east-1.secretsmanager",
+			"VpcEndpointType":   "Interface",
+			"PrivateDnsEnabled": true,
+		})
+	})
+
+	t.Run("scopes endpoint policies to the account and prefix", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::EC2::VPCEndpoint"), map[string]interface{}{
+			"ServiceName": "com.amazonaws.us-east-1.secretsmanager",
+			"PolicyDocument": map[string]interface{}{
+				"Statement": []interface{}{
+					map[string]interface{}{
+						"Effect":    "Allow",
+						"Principal": map[string]interface{}{"AWS": "*"},
+						"Condition": map[string]interface{}{
+							"StringEquals": map[string]interface{}{"aws:PrincipalAccount": "123456789012"},
+						},
+						"Resource": []interface{}{
+							partitionArn(":secretsmanager:us-east-1:123456789012:secret:docs-site-*"),
+							partitionArn(":secretsmanager:us-east-1:123456789012:secret:/docs-site/*"),
+						},
+					},
+				},
+			},
+		})
+	})
+
+	t.Run("deletes workloads before the endpoints", func(t *testing.T) {
+		workloads := map[string]*map[string]interface{}{}
+		for id, workload := range *template.FindResources(jsii.String("AWS::ECS::Service"), nil) {
+			workloads[id] = workload
+		}
+		for id, workload := range *template.FindResources(jsii.String("AWS::Lambda::Function"), map[string]interface{}{
+			"Properties": map[string]interface{}{
+				"Handler":   "handler.lambda_handler",
+				"VpcConfig": assertions.Match_AnyValue(),
+			},
+		}) {
+			workloads[id] = workload
+		}
+		if len(workloads) != 2 {
+			t.Fatalf("found %d workloads, want the service and the migration Lambda", len(workloads))
+		}
+
+		for id, workload := range workloads {
+			dependsOn, _ := (*workload)["DependsOn"].([]interface{})
+			endpoints := 0
+			for _, dependency := range dependsOn {
+				if name, _ := dependency.(string); strings.Contains(name, "Endpoint") {
+					endpoints++
+				}
+			}
+			if endpoints != 10 {
+				t.Errorf("%s depends on %d endpoints, want 10", id, endpoints)
+			}
+		}
+	})
+}
+
+func TestNetwork_ExistingVpc(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := NewAppStack(app, "TestStack", &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Account: jsii.String("123456789012"),
+				Region:  jsii.String("us-east-1"),
+			},
+		},
+		Networking: &NetworkConfig{
+			ExistingVpc: &ExistingVpcConfig{
+				Attributes: &awsec2.VpcAttributes{
+					VpcId:                      jsii.String("vpc-0123456789abcdef0"),
+					VpcCidrBlock:               jsii.String("10.20.0.0/16"),
+					AvailabilityZones:          jsii.Strings("us-east-1a", "us-east-1b"),
+					PublicSubnetIds:            jsii.Strings("subnet-public1", "subnet-public2"),
+					PublicSubnetNames:          jsii.Strings("Ingress"),
+					PrivateSubnetIds:           jsii.Strings("subnet-app1", "subnet-app2"),
+					PrivateSubnetNames:         jsii.Strings("App"),
+					PrivateSubnetRouteTableIds: jsii.Strings("rtb-app1", "rtb-app2"),
+					IsolatedSubnetIds:          jsii.Strings("subnet-data1", "subnet-data2"),
+					IsolatedSubnetNames:        jsii.Strings("Data"),
+				},
+				WorkloadSubnetGroup: "App",
+				DatabaseSubnetGroup: "Data",
+			},
+		},
+	})
+
+	// Act
+	template := assertions.Template_FromStack(stack.Stack, nil)
+
+	// Assert
+	t.Run("creates no VPC", func(_ *testing.T) {
+		template.ResourceCountIs(jsii.String("AWS::EC2::VPC"), jsii.Number(0))
+		template.ResourceCountIs(jsii.String("AWS::EC2::Subnet"), jsii.Number(0))
+	})
+
+	t.Run("runs tasks in the workload subnets", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::ECS::Service"), map[string]interface{}{
+			"NetworkConfiguration": map[string]interface{}{
+				"AwsvpcConfiguration": map[string]interface{}{
+					"AssignPublicIp": "DISABLED",
+					"Subnets":        []interface{}{"subnet-app1", "subnet-app2"},
+				},
+			},
+		})
+	})
+
+	t.Run("runs the migration Lambda in the workload subnets", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::Lambda::Function"), map[string]interface{}{
+			"Handler": "handler.lambda_handler",
+			"VpcConfig": map[string]interface{}{
+				"SubnetIds": []interface{}{"subnet-app1", "subnet-app2"},
+			},
+		})
+	})
+
+	t.Run("places Aurora in the database subnets", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::RDS::DBSubnetGroup"), map[string]interface{}{
+			"SubnetIds": []interface{}{"subnet-data1", "subnet-data2"},
+		})
+	})
+
+	t.Run("places the load balancer in the public subnets", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::LoadBalancer"), map[string]interface{}{
+			"Subnets": []interface{}{"subnet-public1", "subnet-public2"},
+		})
+	})
+}
+
+func TestNetwork_FlowLogs(t *testing.T) {
+	newStack := func(destination FlowLogDestination) awscdk.Stack {
+		app := awscdk.NewApp(nil)
+		stack := awscdk.NewStack(app, jsii.String("NetworkStack"), &awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Account: jsii.String("123456789012"),
+				Region:  jsii.String("us-east-1"),
+			},
+		})
+		NewNetwork(stack, "Network", &NetworkProps{
+			NetworkConfig: NetworkConfig{FlowLogs: destination},
+			Environment:   StagingEnvironment(),
+			Naming:        NewNaming("docs-site"),
+		})
+		return stack
+	}
+
+	t.Run("disabled by default", func(_ *testing.T) {
+		template := assertions.Template_FromStack(newStack(""), nil)
+		template.ResourceCountIs(jsii.String("AWS::EC2::FlowLog"), jsii.Number(0))
+	})
+
+	t.Run("delivers to CloudWatch Logs with the environment retention", func(_ *testing.T) {
+		template := assertions.Template_FromStack(newStack(FlowLogsCloudWatch), nil)
+		template.HasResourceProperties(jsii.String("AWS::Logs::LogGroup"), map[string]interface{}{
+			"LogGroupName":    "/vpc-flow-logs/docs-site",
+			"RetentionInDays": 30,
+		})
+		template.HasResourceProperties(jsii.String("AWS::EC2::FlowLog"), map[string]interface{}{
+			"LogDestinationType":     "cloud-watch-logs",
+			"TrafficType":            "ALL",
+			"MaxAggregationInterval": 60,
+			"LogFormat":              assertions.Match_StringLikeRegexp(jsii.String(`\$\{pkt-srcaddr\} \$\{pkt-dstaddr\}.*\$\{tcp-flags\}`)),
+		})
+	})
+
+	t.Run("delivers to S3 in Athena partitions", func(_ *testing.T) {
+		template := assertions.Template_FromStack(newStack(FlowLogsS3), nil)
+		template.HasResourceProperties(jsii.String("AWS::EC2::FlowLog"), map[string]interface{}{
+			"LogDestinationType": "s3",
+			"DestinationOptions": map[string]interface{}{
+				"fileFormat":               "parquet",
+				"hiveCompatiblePartitions": true,
+				"perHourPartition":         true,
+			},
+		})
+		template.HasResourceProperties(jsii.String("AWS::S3::Bucket"), map[string]interface{}{
+			"LifecycleConfiguration": map[string]interface{}{
+				"Rules": []interface{}{
+					map[string]interface{}{"ExpirationInDays": 30, "Prefix": "vpc-flow-logs/", "Status": "Enabled"},
+				},
+			},
+		})
+	})
+}
+
+func TestNetwork_DualStack(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := NewAppStack(app, "TestStack", &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Account: jsii.String("123456789012"),
+				Region:  jsii.String("us-east-1"),
+			},
+		},
+		Networking: &NetworkConfig{Egress: NetworkEgressNATGateway, VpcEndpoints: true, DualStack: true},
+	})
+
+	// Act
+	template := assertions.Template_FromStack(stack.Stack, nil)
+
+	// Assert
+	t.Run("adds an Amazon provided IPv6 CIDR to the VPC and every subnet", func(t *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::EC2::VPCCidrBlock"), map[string]interface{}{
+			"AmazonProvidedIpv6CidrBlock": true,
+		})
+		subnets := template.FindResources(jsii.String("AWS::EC2::Subnet"), map[string]interface{}{
+			"Properties": map[string]interface{}{
+				"AssignIpv6AddressOnCreation": true,
+				"Ipv6CidrBlock":               assertions.Match_AnyValue(),
+			},
+		})
+		if len(*subnets) != 6 {
+			t.Errorf("found %d dual-stack subnets, want 6", len(*subnets))
+		}
+	})
+
+	t.Run("routes private IPv6 traffic through an egress-only internet gateway", func(t *testing.T) {
+		template.ResourceCountIs(jsii.String("AWS::EC2::EgressOnlyInternetGateway"), jsii.Number(1))
+		routes := template.FindResources(jsii.String("AWS::EC2::Route"), map[string]interface{}{
+			"Properties": map[string]interface{}{
+				"DestinationIpv6CidrBlock":    "::/0",
+				"EgressOnlyInternetGatewayId": assertions.Match_AnyValue(),
+			},
+		})
+		if len(*routes) != 2 {
+			t.Errorf("found %d IPv6 routes through the egress-only gateway, want 2", len(*routes))
+		}
+	})
+
+	t.Run("serves the load balancer over IPv4 and IPv6", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::LoadBalancer"), map[string]interface{}{
+			"Type":          "application",
+			"IpAddressType": "dualstack",
+		})
+		template.HasResourceProperties(jsii.String("AWS::EC2::SecurityGroup"), map[string]interface{}{
+			"SecurityGroupIngress": assertions.Match_ArrayWith(&[]interface{}{
+				assertions.Match_ObjectLike(&map[string]interface{}{"CidrIpv6": "::/0", "FromPort": 80, "ToPort": 80}),
+			}),
+		})
+	})
+
+	t.Run("lets the workloads reach out over IPv6", func(_ *testing.T) {
+		for _, description := range []string{
+			"Allow outbound connections from ECS service to RDS and other AWS services",
+			"Allow outbound connection to RDS Postgres for DB migrations",
+		} {
+			template.HasResourceProperties(jsii.String("AWS::EC2::SecurityGroup"), map[string]interface{}{
+				"GroupDescription": description,
+				"SecurityGroupEgress": assertions.Match_ArrayWith(&[]interface{}{
+					assertions.Match_ObjectLike(&map[string]interface{}{"CidrIpv6": "::/0", "IpProtocol": "-1"}),
+				}),
+			})
+		}
+		template.HasResourceProperties(jsii.String("AWS::Lambda::Function"), map[string]interface{}{
+			"VpcConfig": assertions.Match_ObjectLike(&map[string]interface{}{"Ipv6AllowedForDualStack": true}),
+		})
+	})
+
+	t.Run("admits HTTPS from the VPC's IPv6 CIDR to the interface endpoints", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::EC2::SecurityGroup"), map[string]interface{}{
+			"GroupDescription": "Allow HTTPS from the VPC to the interface endpoints",
+			"SecurityGroupIngress": assertions.Match_ArrayWith(&[]interface{}{
+				assertions.Match_ObjectLike(&map[string]interface{}{
+					"CidrIpv6": assertions.Match_ObjectLike(&map[string]interface{}{"Fn::Select": assertions.Match_AnyValue()}),
+					"FromPort": 443,
+					"ToPort":   443,
+				}),
+			}),
+		})
+	})
+}
+
+func TestNetwork_DualStackRejectsExistingVpc(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("NetworkStack"), &awscdk.StackProps{
+		Env: &awscdk.Environment{
+			Account: jsii.String("123456789012"),
+			Region:  jsii.String("us-east-1"),
+		},
+	})
+
+	// Act
+	NewNetwork(stack, "Network", &NetworkProps{
+		NetworkConfig: NetworkConfig{
+			DualStack: true,
+			ExistingVpc: &ExistingVpcConfig{
+				Attributes: &awsec2.VpcAttributes{
+					VpcId:             jsii.String("vpc-0123456789abcdef0"),
+					AvailabilityZones: jsii.Strings("us-east-1a", "us-east-1b"),
+					PublicSubnetIds:   jsii.Strings("subnet-public1", "subnet-public2"),
+				},
+			},
+		},
+	})
+
+	// Assert
+	assertions.Annotations_FromStack(stack).HasError(jsii.String("*"), assertions.Match_StringLikeRegexp(jsii.String("DualStack")))
+}
+
+// partitionArn is the template value of an ARN formatted with the stack's partition, given the ARN after it.
+func partitionArn(suffix string) map[string]interface{} {
+	return map[string]interface{}{
+		"Fn::Join": []interface{}{"", []interface{}{"arn:", map[string]interface{}{"Ref": "AWS::Partition"}, suffix}},
+	}
+}
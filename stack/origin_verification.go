@@ -0,0 +1,134 @@
+package stack
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsapigateway"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awselasticloadbalancingv2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3assets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssecretsmanager"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+const (
+	// OriginVerifyHeader carries the shared secret proving that a request to the internet-facing load balancer
+	// came through API Gateway.
+	OriginVerifyHeader = "X-Origin-Verify"
+
+	// originSecretRotationDays is how often the origin secret is replaced.
+	originSecretRotationDays = 30
+)
+
+// OriginVerification lets the internet-facing load balancer reject requests that bypass API Gateway. The
+// listener only forwards requests carrying the current secret in OriginVerifyHeader and answers 403 otherwise.
+type OriginVerification struct {
+	// Secret is the header value API Gateway injects into every integration request.
+	Secret awssecretsmanager.ISecret
+	// SecretName is the physical name of Secret, used to resolve the value at deploy time without a cross-stack
+	// reference.
+	SecretName string
+	// ListenerRule forwards requests carrying the secret to the service.
+	ListenerRule awselasticloadbalancingv2.ApplicationListenerRule
+}
+
+// newOriginVerification creates the origin secret and the listener rule checking it. The listener's default
+// action must reject requests.
+func newOriginVerification(scope constructs.Construct, listener awselasticloadbalancingv2.ApplicationListener, targetGroup awselasticloadbalancingv2.IApplicationTargetGroup, naming *Naming, environment *EnvironmentConfig) *OriginVerification {
+	secretName := naming.ParameterPath("api", "origin-verify")
+	secret := awssecretsmanager.NewSecret(scope, jsii.String("OriginSecret"), &awssecretsmanager.SecretProps{
+		SecretName:  jsii.String(secretName),
+		Description: jsii.String(fmt.Sprintf("Value of the %s header API Gateway sends to the load balancer", OriginVerifyHeader)),
+		GenerateSecretString: &awssecretsmanager.SecretStringGenerator{
+			PasswordLength:     jsii.Number(48),
+			ExcludePunctuation: jsii.Bool(true),
+		},
+		RemovalPolicy: environment.statefulRemovalPolicy(),
+	})
+
+	verification := &OriginVerification{
+		Secret:     secret,
+		SecretName: secretName,
+	}
+	verification.ListenerRule = awselasticloadbalancingv2.NewApplicationListenerRule(scope, jsii.String("OriginVerifyRule"), &awselasticloadbalancingv2.ApplicationListenerRuleProps{
+		Listener: listener,
+		Priority: jsii.Number(1),
+		Conditions: &[]awselasticloadbalancingv2.ListenerCondition{
+			awselasticloadbalancingv2.ListenerCondition_HttpHeader(jsii.String(OriginVerifyHeader), jsii.Strings(*verification.headerValue())),
+		},
+		TargetGroups: &[]awselasticloadbalancingv2.IApplicationTargetGroup{targetGroup},
+	})
+	// The condition resolves the secret by name, so the rule must wait for it
+	verification.ListenerRule.Node().AddDependency(secret)
+
+	return verification
+}
+
+// headerValue is a dynamic reference CloudFormation resolves to the current secret when it deploys the resource.
+func (o *OriginVerification) headerValue() *string {
+	return awscdk.SecretValue_SecretsManager(jsii.String(o.SecretName), nil).UnsafeUnwrap()
+}
+
+// integrationParameters returns the integration request parameters injecting the header.
+func (o *OriginVerification) integrationParameters() *map[string]*string {
+	return &map[string]*string{
+		"integration.request.header." + OriginVerifyHeader: jsii.String(fmt.Sprintf("'%s'", *o.headerValue())),
+	}
+}
+
+// addRotation rotates the secret on a schedule. The rotation function lets the listener rule accept both the
+// current and the pending value while it redeploys the API with the pending value, then drops the old value.
+func (o *OriginVerification) addRotation(scope constructs.Construct, api awsapigateway.RestApi, environment *EnvironmentConfig) {
+	stack := awscdk.Stack_Of(scope)
+	partition, region := *stack.Partition(), *stack.Region()
+
+	rotationFunction := awslambda.NewFunction(scope, jsii.String("OriginSecretRotation"), &awslambda.FunctionProps{
+		Description: jsii.String("Rotates the origin verification header of API Gateway and the load balancer"),
+		Handler:     jsii.String("handler.lambda_handler"),
+		Runtime:     awslambda.Runtime_PYTHON_3_12(),
+		Code: awslambda.AssetCode_FromAsset(jsii.String(filepath.Join(getThisFileDir(), "../origin_secret_rotation")), &awss3assets.AssetOptions{
+			Exclude: jsii.Strings("*_test.py", "requirements_test.txt", "__pycache__"),
+		}),
+		Environment: &map[string]*string{
+			"HEADER_NAME":       jsii.String(OriginVerifyHeader),
+			"LISTENER_RULE_ARN": o.ListenerRule.ListenerRuleArn(),
+			"REST_API_ID":       api.RestApiId(),
+			"STAGE_NAME":        api.DeploymentStage().StageName(),
+		},
+		Timeout: awscdk.Duration_Seconds(jsii.Number(60)),
+	})
+	rotationFunction.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	rotationFunction.AddToRolePolicy(awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+		Effect:    awsiam.Effect_ALLOW,
+		Actions:   jsii.Strings("elasticloadbalancing:ModifyRule"),
+		Resources: &[]*string{o.ListenerRule.ListenerRuleArn()},
+	}))
+	rotationFunction.AddToRolePolicy(awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+		Effect:  awsiam.Effect_ALLOW,
+		Actions: jsii.Strings("apigateway:GET", "apigateway:PATCH", "apigateway:POST"),
+		Resources: &[]*string{
+			jsii.String(fmt.Sprintf("arn:%s:apigateway:%s::/restapis/%s/resources", partition, region, *api.RestApiId())),
+			jsii.String(fmt.Sprintf("arn:%s:apigateway:%s::/restapis/%s/resources/*", partition, region, *api.RestApiId())),
+			jsii.String(fmt.Sprintf("arn:%s:apigateway:%s::/restapis/%s/deployments", partition, region, *api.RestApiId())),
+		},
+	}))
+	rotationFunction.AddToRolePolicy(awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+		Effect:    awsiam.Effect_ALLOW,
+		Actions:   jsii.Strings("secretsmanager:GetRandomPassword"),
+		Resources: jsii.Strings("*"),
+	}))
+
+	// Created next to the function so that the secret's stack never references the API's stack
+	schedule := awssecretsmanager.NewRotationSchedule(scope, jsii.String("OriginSecretRotationSchedule"), &awssecretsmanager.RotationScheduleProps{
+		Secret:                    o.Secret,
+		RotationLambda:            rotationFunction,
+		AutomaticallyAfter:        awscdk.Duration_Days(jsii.Number(originSecretRotationDays)),
+		RotateImmediatelyOnUpdate: jsii.Bool(false),
+	})
+	schedule.Node().AddDependency(api.DeploymentStage())
+}
@@ -0,0 +1,219 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsssm"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Output names published by the stack. They are the CloudFormation output keys and the property names of the
+// outputs manifest, and must not change once consumers depend on them.
+const (
+	OutputAccount                          = "Account"
+	OutputRegion                           = "Region"
+	OutputECRRepositoryURI                 = "ECRRepositoryURI"
+	OutputECSClusterName                   = "ECSClusterName"
+	OutputCognitoUserPoolID                = "CognitoUserPoolID"
+	OutputCognitoUserPoolClientID          = "CognitoUserPoolClientID"
+	OutputCognitoHostedUIURL               = "CognitoHostedUIURL"
+	OutputCognitoHostedUIBaseURL           = "CognitoHostedUIBaseURL"
+	OutputAPIGatewayURL                    = "APIGatewayURL"
+	OutputRDSPostgresCredentialsSecretARN  = "RDSPostgresCredentialsSecretARN"
+	OutputRDSPostgresClusterARN            = "RDSPostgresInstanceARN"
+	OutputRDSPostgresReaderEndpoint        = "RDSPostgresReaderEndpoint"
+	OutputRDSPostgresSchemaEnsureLambdaARN = "RDSPostgresSchemaEnsureLambdaARN"
+	OutputRDSPostgresSchemaVersion         = "RDSPostgresSchemaVersion"
+	OutputBucketName                       = "BucketName"
+	OutputBedrockKnowledgeBaseRoleARN      = "BedrockKnowledgeBaseRoleArn"
+	OutputBedrockAgentRoleARN              = "BedrockAgentRoleArn"
+	OutputFrontendBucketName               = "FrontendBucketName"
+	OutputFrontendURL                      = "FrontendURL"
+	OutputCloudFrontDistributionID         = "CloudFrontDistributionID"
+	OutputCloudFrontDistributionDomainName = "CloudFrontDistributionDomainName"
+	OutputGitHubActionsRoleARN             = "GitHubActionsRoleARN"
+
+	// OutputsSchemaFile is the outputs manifest file name, written next to the synthesized templates.
+	OutputsSchemaFile = "outputs.schema.json"
+)
+
+// OutputType describes the shape of an output value so consumers can validate it.
+type OutputType string
+
+const (
+	// OutputTypeString is any non-empty string, such as a resource name or ID.
+	OutputTypeString OutputType = "string"
+	// OutputTypeARN is an Amazon Resource Name.
+	OutputTypeARN OutputType = "arn"
+	// OutputTypeURL is an absolute URL.
+	OutputTypeURL OutputType = "url"
+	// OutputTypeAccountID is a 12 digit AWS account ID.
+	OutputTypeAccountID OutputType = "account-id"
+	// OutputTypeRegion is an AWS region name.
+	OutputTypeRegion OutputType = "region"
+)
+
+// StackOutput is a value the stack publishes as a CloudFormation output, an optional export and optional
+// Parameter Store parameters.
+type StackOutput struct {
+	// Name is the CloudFormation output key.
+	Name string
+	// Type describes the shape of the value.
+	Type OutputType
+	// Description is shown in the CloudFormation console and the manifest.
+	Description string
+	// Stack is the name of the stack declaring the output.
+	Stack string
+	// ExportName is the CloudFormation export name, empty when the output is not exported.
+	ExportName string
+	// Parameters are the full Parameter Store names holding the value.
+	Parameters []string
+}
+
+// OutputRegistry records every output the stack publishes so that the outputs manifest describes exactly what
+// was synthesized.
+type OutputRegistry struct {
+	outputs []StackOutput
+}
+
+// outputDefinition declares an output before the naming strategy turns it into a StackOutput.
+type outputDefinition struct {
+	name         string
+	outputType   OutputType
+	description  string
+	exportSuffix string
+	parameters   []string
+	value        *string
+}
+
+// Outputs returns the published outputs in declaration order.
+func (r *OutputRegistry) Outputs() []StackOutput {
+	return r.outputs
+}
+
+// Output returns the published output with the given name.
+func (r *OutputRegistry) Output(name string) (StackOutput, bool) {
+	for _, output := range r.outputs {
+		if output.Name == name {
+			return output, true
+		}
+	}
+	return StackOutput{}, false
+}
+
+// Schema returns a JSON Schema describing the outputs file written by `cdk deploy --outputs-file`. Each output
+// carries its export name and Parameter Store names as "x-exportName" and "x-parameters".
+func (r *OutputRegistry) Schema() map[string]interface{} {
+	stackNames := []string{}
+	properties := map[string]map[string]interface{}{}
+	required := map[string][]string{}
+	for _, output := range r.outputs {
+		if _, ok := properties[output.Stack]; !ok {
+			stackNames = append(stackNames, output.Stack)
+			properties[output.Stack] = map[string]interface{}{}
+		}
+		properties[output.Stack][output.Name] = output.schema()
+		required[output.Stack] = append(required[output.Stack], output.Name)
+	}
+
+	stacks := map[string]interface{}{}
+	for _, stackName := range stackNames {
+		stacks[stackName] = map[string]interface{}{
+			"type":       "object",
+			"properties": properties[stackName],
+			"required":   required[stackName],
+		}
+	}
+
+	return map[string]interface{}{
+		"$schema":    "https://json-schema.org/draft/2020-12/schema",
+		"title":      fmt.Sprintf("Outputs of %s", strings.Join(stackNames, ", ")),
+		"type":       "object",
+		"properties": stacks,
+		"required":   stackNames,
+	}
+}
+
+// WriteSchema writes the outputs manifest as JSON to path.
+func (r *OutputRegistry) WriteSchema(path string) error {
+	content, err := json.MarshalIndent(r.Schema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode outputs schema: %w", err)
+	}
+
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write outputs schema %s: %w", path, err)
+	}
+	return nil
+}
+
+// schema returns the JSON Schema of a single output value.
+func (o StackOutput) schema() map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":        "string",
+		"description": o.Description,
+		"x-type":      o.Type,
+	}
+	switch o.Type {
+	case OutputTypeARN:
+		schema["pattern"] = `^arn:aws[a-z-]*:`
+	case OutputTypeURL:
+		schema["format"] = "uri"
+	case OutputTypeAccountID:
+		schema["pattern"] = `^[0-9]{12}$`
+	case OutputTypeRegion:
+		schema["pattern"] = `^[a-z]{2}(-gov)?-[a-z]+-[0-9]$`
+	default:
+		schema["minLength"] = 1
+	}
+	if o.ExportName != "" {
+		schema["x-exportName"] = o.ExportName
+	}
+	if len(o.Parameters) > 0 {
+		schema["x-parameters"] = o.Parameters
+	}
+	return schema
+}
+
+// publishOutputs creates the CloudFormation output, export and Parameter Store parameters of every definition.
+func publishOutputs(stack awscdk.Stack, naming *Naming, definitions []outputDefinition) *OutputRegistry {
+	registry := &OutputRegistry{}
+	for _, definition := range definitions {
+		output := StackOutput{
+			Name:        definition.name,
+			Type:        definition.outputType,
+			Description: definition.description,
+			Stack:       *stack.StackName(),
+		}
+
+		outputProps := &awscdk.CfnOutputProps{
+			Value:       definition.value,
+			Description: jsii.String(definition.description),
+		}
+		if definition.exportSuffix != "" {
+			output.ExportName = naming.ExportName(definition.exportSuffix)
+			outputProps.ExportName = jsii.String(output.ExportName)
+		}
+		awscdk.NewCfnOutput(stack, jsii.String(definition.name), outputProps)
+
+		for _, parameter := range definition.parameters {
+			parameterName := naming.ParameterPath(parameter)
+			// Create a clean construct ID from the parameter key
+			constructID := strings.ReplaceAll(strings.ReplaceAll(parameter, "/", ""), "-", "")
+			awsssm.NewStringParameter(stack, jsii.String(fmt.Sprintf("Param%s", constructID)), &awsssm.StringParameterProps{
+				ParameterName: jsii.String(parameterName),
+				StringValue:   definition.value,
+				Description:   jsii.String(fmt.Sprintf("Configuration parameter for %s", parameterName)),
+				Tier:          awsssm.ParameterTier_STANDARD,
+			})
+			output.Parameters = append(output.Parameters, parameterName)
+		}
+
+		registry.outputs = append(registry.outputs, output)
+	}
+	return registry
+}
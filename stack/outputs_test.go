@@ -0,0 +1,103 @@
+package stack
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestOutputRegistry_DescribesPublishedOutputs(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := NewAppStack(app, "TestStack", &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Region: jsii.String("us-east-1"),
+			},
+		},
+		Naming: NewNaming("docs-site"),
+	})
+	template := assertions.Template_FromStack(stack.Stack, nil)
+
+	t.Run("every registered output is in the template", func(t *testing.T) {
+		outputs := *template.FindOutputs(jsii.String("*"), nil)
+		for _, output := range stack.Outputs.Outputs() {
+			if _, ok := outputs[output.Name]; !ok {
+				t.Errorf("output %s is not in the template", output.Name)
+			}
+		}
+	})
+
+	t.Run("exported outputs use the naming prefix", func(t *testing.T) {
+		output, ok := stack.Outputs.Output(OutputECRRepositoryURI)
+		if !ok {
+			t.Fatalf("output %s is not registered", OutputECRRepositoryURI)
+		}
+		if output.ExportName != "DocsSite-ECR-Repository-URI" {
+			t.Errorf("ExportName = %q", output.ExportName)
+		}
+		template.HasOutput(jsii.String(OutputECRRepositoryURI), map[string]interface{}{
+			"Export": map[string]interface{}{"Name": "DocsSite-ECR-Repository-URI"},
+		})
+	})
+
+	t.Run("outputs are mirrored to Parameter Store", func(t *testing.T) {
+		output, _ := stack.Outputs.Output(OutputRegion)
+		want := []string{"/docs-site/backend/aws-region", "/docs-site/backend/cognito-region", "/docs-site/frontend/aws-region", "/docs-site/deployment/aws-region"}
+		if len(output.Parameters) != len(want) {
+			t.Fatalf("Parameters = %v, want %v", output.Parameters, want)
+		}
+		for i, name := range want {
+			if output.Parameters[i] != name {
+				t.Errorf("Parameters[%d] = %q, want %q", i, output.Parameters[i], name)
+			}
+			template.HasResourceProperties(jsii.String("AWS::SSM::Parameter"), map[string]interface{}{
+				"Name":  name,
+				"Value": "us-east-1",
+			})
+		}
+	})
+
+	t.Run("writes a JSON schema for the outputs file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), OutputsSchemaFile)
+		if err := stack.Outputs.WriteSchema(path); err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var schema struct {
+			Required   []string `json:"required"`
+			Properties map[string]struct {
+				Required   []string                          `json:"required"`
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"properties"`
+		}
+		if err := json.Unmarshal(content, &schema); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(schema.Required) != 1 || schema.Required[0] != "TestStack" {
+			t.Fatalf("required = %v, want [TestStack]", schema.Required)
+		}
+		outputs := schema.Properties["TestStack"]
+		if len(outputs.Required) != len(stack.Outputs.Outputs()) {
+			t.Errorf("stack requires %d outputs, registry has %d", len(outputs.Required), len(stack.Outputs.Outputs()))
+		}
+		roleARN := outputs.Properties[OutputGitHubActionsRoleARN]
+		if roleARN["x-type"] != string(OutputTypeARN) || roleARN["pattern"] == nil {
+			t.Errorf("%s schema = %v", OutputGitHubActionsRoleARN, roleARN)
+		}
+		apiURL := outputs.Properties[OutputAPIGatewayURL]
+		if apiURL["format"] != "uri" || apiURL["x-exportName"] != "DocsSite-API-Gateway-URL" {
+			t.Errorf("%s schema = %v", OutputAPIGatewayURL, apiURL)
+		}
+	})
+}
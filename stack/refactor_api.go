@@ -0,0 +1,209 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsapigateway"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscognito"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awselasticloadbalancingv2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsroute53"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsroute53targets"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// RefactorAPIProps defines the properties for the RefactorAPI construct.
+type RefactorAPIProps struct {
+	// LoadBalancer is the internet-facing load balancer requests are proxied to. Required unless VpcLinkTarget
+	// is set.
+	LoadBalancer awselasticloadbalancingv2.IApplicationLoadBalancer
+
+	// OriginVerification is the origin header the internet-facing load balancer requires. When set, every
+	// integration request carries it and the secret is rotated on a schedule.
+	OriginVerification *OriginVerification
+
+	// DomainName is the name the load balancer's certificate covers. When set, requests are proxied over HTTPS to
+	// it instead of over HTTP to the load balancer's DNS name.
+	DomainName string
+
+	// VpcLinkTarget is the internal Network Load Balancer in front of an internal load balancer. When set,
+	// requests are proxied through a VPC link to it instead of to LoadBalancer.
+	VpcLinkTarget awselasticloadbalancingv2.INetworkLoadBalancer
+
+	// Domains serves the API at the root of APIDomainName when both are set.
+	Domains *DomainConfig
+
+	// UserPool authorizes every route except /health, /swagger and /auth. Required.
+	UserPool awscognito.IUserPool
+
+	// Environment supplies the removal policy. Defaults to DevEnvironment.
+	Environment *EnvironmentConfig
+
+	// Naming derives the API and authorizer names. Defaults to DefaultNamePrefix.
+	Naming *Naming
+}
+
+// RefactorAPI is the regional REST API that proxies every path to the backend load balancer.
+type RefactorAPI struct {
+	constructs.Construct
+
+	// RestAPI is the REST API with a Cognito authorized {proxy+} resource.
+	RestAPI awsapigateway.IRestApi
+	// URL is the URL of the deployment stage: its custom domain when one is configured, otherwise its invoke URL.
+	URL string
+}
+
+// NewRefactorAPI creates the REST API, its Cognito authorizer and the HTTP proxy integration.
+func NewRefactorAPI(scope constructs.Construct, id string, props *RefactorAPIProps) *RefactorAPI {
+	this := constructs.NewConstruct(scope, &id)
+	environment := environmentOrDefault(props.Environment)
+	naming := namingOrDefault(props.Naming)
+
+	// Access logs of the deployment stage
+	accessLogGroup := awslogs.NewLogGroup(this, jsii.String("AccessLogs"), &awslogs.LogGroupProps{
+		LogGroupName:  jsii.String(naming.LogGroupName("apigateway")),
+		Retention:     environment.LogRetention,
+		RemovalPolicy: environment.RemovalPolicy,
+	})
+
+	// Create API Gateway REST API
+	api := awsapigateway.NewRestApi(this, jsii.String("CodeRefactorAPI"), &awsapigateway.RestApiProps{
+		RestApiName: jsii.String(naming.Name("api")),
+		Description: jsii.String("API Gateway for Code Refactoring Tool"),
+		EndpointTypes: &[]awsapigateway.EndpointType{
+			awsapigateway.EndpointType_REGIONAL,
+		},
+		DefaultCorsPreflightOptions: &awsapigateway.CorsOptions{
+			AllowOrigins: &[]*string{jsii.String("*")},
+			AllowMethods: &[]*string{jsii.String("GET"), jsii.String("POST"), jsii.String("PUT"), jsii.String("DELETE"), jsii.String("OPTIONS")},
+			AllowHeaders: &[]*string{jsii.String("Content-Type"), jsii.String("Authorization")},
+		},
+		DeployOptions: &awsapigateway.StageOptions{
+			AccessLogDestination: awsapigateway.NewLogGroupLogDestination(accessLogGroup),
+			AccessLogFormat:      awsapigateway.AccessLogFormat_JsonWithStandardFields(nil),
+		},
+	})
+
+	// Apply removal policy to API Gateway for clean deletion
+	api.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	// Create Cognito Authorizer
+	cognitoAuthorizer := awsapigateway.NewCognitoUserPoolsAuthorizer(this, jsii.String("CodeRefactorAuthorizer"), &awsapigateway.CognitoUserPoolsAuthorizerProps{
+		CognitoUserPools: &[]awscognito.IUserPool{props.UserPool},
+		AuthorizerName:   jsii.String(naming.Name("authorizer")),
+	})
+
+	integration := newBackendIntegration(this, props, naming, environment)
+	if props.OriginVerification != nil {
+		// The integrations resolve the secret by name, so the API must wait for it
+		api.Node().AddDependency(props.OriginVerification.Secret)
+		props.OriginVerification.addRotation(this, api, environment)
+	}
+
+	// Add proxy resource to handle all paths
+	api.Root().AddProxy(&awsapigateway.ProxyResourceOptions{
+		DefaultIntegration: integration,
+		DefaultMethodOptions: &awsapigateway.MethodOptions{
+			Authorizer:        cognitoAuthorizer,
+			AuthorizationType: awsapigateway.AuthorizationType_COGNITO,
+		},
+		AnyMethod: jsii.Bool(true),
+	})
+
+	// Add public endpoints without authorization (health check, swagger docs, auth endpoints)
+	healthResource := api.Root().AddResource(jsii.String("health"), nil)
+	healthResource.AddMethod(jsii.String("GET"), integration, &awsapigateway.MethodOptions{
+		AuthorizationType: awsapigateway.AuthorizationType_NONE,
+	})
+
+	docsResource := api.Root().AddResource(jsii.String("swagger"), nil)
+	docsResource.AddMethod(jsii.String("GET"), integration, &awsapigateway.MethodOptions{
+		AuthorizationType: awsapigateway.AuthorizationType_NONE,
+	})
+
+	// Add auth resource for authentication endpoints and documentation
+	authResource := api.Root().AddResource(jsii.String("auth"), nil)
+	authResource.AddMethod(jsii.String("GET"), integration, &awsapigateway.MethodOptions{
+		AuthorizationType: awsapigateway.AuthorizationType_NONE,
+	})
+
+	url := *api.Url()
+	if props.Domains != nil && props.Domains.APIDomainName != "" {
+		url = addCustomDomain(this, api, props.Domains, environment)
+	}
+
+	return &RefactorAPI{
+		Construct: this,
+		RestAPI:   api,
+		URL:       url,
+	}
+}
+
+// addCustomDomain maps the deployment stage to the root of the API domain name, points the name at it and
+// returns the stage's URL on it.
+func addCustomDomain(scope constructs.Construct, api awsapigateway.RestApi, domains *DomainConfig, environment *EnvironmentConfig) string {
+	zone := domains.hostedZone(scope)
+	domainName := awsapigateway.NewDomainName(scope, jsii.String("CustomDomain"), &awsapigateway.DomainNameProps{
+		DomainName:     jsii.String(domains.APIDomainName),
+		Certificate:    newDomainCertificate(scope, "CustomDomainCertificate", domains.APIDomainName, zone, environment),
+		EndpointType:   awsapigateway.EndpointType_REGIONAL,
+		SecurityPolicy: awsapigateway.SecurityPolicy_TLS_1_2,
+	})
+	domainName.ApplyRemovalPolicy(environment.RemovalPolicy)
+	domainName.AddBasePathMapping(api, &awsapigateway.BasePathMappingOptions{
+		Stage: api.DeploymentStage(),
+	})
+
+	record := awsroute53.NewARecord(scope, jsii.String("CustomDomainAlias"), &awsroute53.ARecordProps{
+		Zone:       zone,
+		RecordName: jsii.String(domains.APIDomainName),
+		Target:     awsroute53.RecordTarget_FromAlias(awsroute53targets.NewApiGatewayDomain(domainName)),
+	})
+	record.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	return domainURL(domains.APIDomainName) + "/"
+}
+
+// newBackendIntegration proxies requests to the internet-facing load balancer, or through a VPC link when the
+// load balancer is internal.
+func newBackendIntegration(scope constructs.Construct, props *RefactorAPIProps, naming *Naming, environment *EnvironmentConfig) awsapigateway.Integration {
+	if props.VpcLinkTarget == nil {
+		// Direct integration to the internet-facing ALB
+		albURL := props.backendURL(props.LoadBalancer.LoadBalancerDnsName())
+		integrationProps := &awsapigateway.HttpIntegrationProps{
+			Proxy: jsii.Bool(true),
+		}
+		if props.OriginVerification != nil {
+			integrationProps.Options = &awsapigateway.IntegrationOptions{
+				RequestParameters: props.OriginVerification.integrationParameters(),
+			}
+		}
+		return awsapigateway.NewHttpIntegration(jsii.String(albURL), integrationProps)
+	}
+
+	vpcLink := awsapigateway.NewVpcLink(scope, jsii.String("VpcLink"), &awsapigateway.VpcLinkProps{
+		VpcLinkName: jsii.String(naming.Name("vpc-link")),
+		Description: jsii.String("Private connection from API Gateway to the backend load balancer"),
+		Targets:     &[]awselasticloadbalancingv2.INetworkLoadBalancer{props.VpcLinkTarget},
+	})
+	vpcLink.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	// The VPC link connects to the NLB whatever the host, which API Gateway only uses to verify the certificate
+	nlbURL := props.backendURL(props.VpcLinkTarget.LoadBalancerDnsName())
+	return awsapigateway.NewHttpIntegration(jsii.String(nlbURL), &awsapigateway.HttpIntegrationProps{
+		Proxy: jsii.Bool(true),
+		Options: &awsapigateway.IntegrationOptions{
+			ConnectionType: awsapigateway.ConnectionType_VPC_LINK,
+			VpcLink:        vpcLink,
+		},
+	})
+}
+
+// backendURL is the HTTPS URL of DomainName, or the HTTP URL of the given load balancer DNS name without one.
+func (p *RefactorAPIProps) backendURL(dnsName *string) string {
+	if p.DomainName != "" {
+		return fmt.Sprintf("https://%s", p.DomainName)
+	}
+	return fmt.Sprintf("http://%s", *dnsName)
+}
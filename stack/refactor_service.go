@@ -0,0 +1,453 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsecr"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsecs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awselasticloadbalancingv2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awselasticloadbalancingv2targets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslogs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssecretsmanager"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// LoadBalancerConfig selects how the backend load balancer is exposed.
+type LoadBalancerConfig struct {
+	// Internal places the load balancer in the workload subnets without a public address and fronts it with an
+	// internal Network Load Balancer that API Gateway reaches through a VPC link. By default the load balancer is
+	// internet-facing and API Gateway calls its public DNS name.
+	Internal bool
+
+	// HTTPS serves the load balancer over TLS with an ACM certificate. By default it only listens for plain HTTP.
+	HTTPS *HTTPSConfig
+}
+
+// RefactorServiceProps defines the properties for the RefactorService construct.
+type RefactorServiceProps struct {
+	// Vpc is the VPC the cluster, load balancer and tasks are placed in. Required.
+	Vpc awsec2.IVpc
+
+	// VpcSubnets selects the subnets the tasks run in; tasks in public subnets get a public IP. An internal load
+	// balancer uses them too, an internet-facing one always uses the public subnets. Defaults to the public subnets.
+	VpcSubnets *awsec2.SubnetSelection
+
+	// LoadBalancer selects whether the load balancer is internet-facing or internal.
+	LoadBalancer LoadBalancerConfig
+
+	// Database is the vector database the tasks connect to. Required.
+	Database *VectorDatabase
+
+	// KnowledgeBase is the bucket the backend creates knowledge bases from. Required.
+	KnowledgeBase *KnowledgeBaseBucket
+
+	// Bedrock supplies the service roles the backend passes to Bedrock. Required.
+	Bedrock *BedrockRoles
+
+	// Users is the user pool the backend validates tokens against. Required.
+	Users *UserDirectory
+
+	// Environment selects task size, task count, log retention and removal policy. Defaults to DevEnvironment.
+	Environment *EnvironmentConfig
+
+	// Naming derives the repository, log group and parameter names. Defaults to DefaultNamePrefix.
+	Naming *Naming
+
+	// VectorTableName is the vector store table used by the backend. Defaults to RDSPostgresTableName.
+	VectorTableName string
+
+	// OutputsStackName names the stack whose outputs the tasks may read. Defaults to the enclosing stack.
+	OutputsStackName string
+
+	// AccessLogs receives the load balancer access logs under "alb/". Leave nil to disable them.
+	AccessLogs awss3.IBucket
+
+	// DualStack serves the load balancer over IPv4 and IPv6 and lets the tasks reach out over IPv6. The subnets
+	// must have IPv6 CIDRs.
+	DualStack bool
+}
+
+// RefactorService runs the backend container on Fargate behind an Application Load Balancer. The load balancer is
+// either internet-facing or internal behind a Network Load Balancer for an API Gateway VPC link. The image is
+// pulled from the "latest" tag of its own ECR repository.
+type RefactorService struct {
+	constructs.Construct
+
+	// Cluster is the ECS cluster the service runs in.
+	Cluster awsecs.ICluster
+	// TaskDef is the Fargate task definition with the backend container listening on port 8080.
+	TaskDef awsecs.IFargateTaskDefinition
+	// Service is the Fargate service registered with the load balancer target group.
+	Service awsecs.IFargateService
+	// EcrRepo is the repository CI pushes the backend image to.
+	EcrRepo awsecr.IRepository
+	// LogGroup receives the container logs.
+	LogGroup awslogs.ILogGroup
+	// LoadBalancer forwards HTTP on port 80, or HTTPS on port 443, to the service.
+	LoadBalancer awselasticloadbalancingv2.IApplicationLoadBalancer
+	// DomainName is the name API Gateway calls the load balancer by over HTTPS, empty without LoadBalancer.HTTPS.
+	DomainName string
+	// VpcLinkTarget forwards TCP on the listener port to the internal load balancer, nil unless
+	// LoadBalancer.Internal is set.
+	VpcLinkTarget awselasticloadbalancingv2.INetworkLoadBalancer
+	// OriginVerification restricts the internet-facing load balancer to requests from API Gateway, nil when
+	// LoadBalancer.Internal is set.
+	OriginVerification *OriginVerification
+}
+
+// NewRefactorService creates the ECS cluster, task definition, ECR repository, load balancer and Fargate service.
+func NewRefactorService(scope constructs.Construct, id string, props *RefactorServiceProps) *RefactorService {
+	this := constructs.NewConstruct(scope, &id)
+	environment := environmentOrDefault(props.Environment)
+
+	service := &RefactorService{Construct: this}
+	service.createTaskDefinition(props, environment)
+	service.createService(props, environment)
+
+	return service
+}
+
+// createTaskDefinition creates the ECS cluster, ECR repository and the task definition running the backend container
+func (s *RefactorService) createTaskDefinition(props *RefactorServiceProps, environment *EnvironmentConfig) {
+	stack := awscdk.Stack_Of(s.Construct)
+	partition, region, account := *stack.Partition(), *stack.Region(), *stack.Account()
+	naming := namingOrDefault(props.Naming)
+	vectorTableName := props.VectorTableName
+	if vectorTableName == "" {
+		vectorTableName = RDSPostgresTableName
+	}
+	outputsStackName := props.OutputsStackName
+	if outputsStackName == "" {
+		outputsStackName = *stack.StackName()
+	}
+
+	// ECS Cluster
+	cluster := awsecs.NewCluster(s.Construct, jsii.String("RefactorCluster"), &awsecs.ClusterProps{
+		Vpc: props.Vpc,
+	})
+
+	// Apply removal policy to ECS cluster for clean deletion
+	cluster.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	// CloudWatch Log Group
+	logGroup := awslogs.NewLogGroup(s.Construct, jsii.String("FargateLogGroup"), &awslogs.LogGroupProps{
+		LogGroupName:  jsii.String(naming.LogGroupName("ecs")),
+		Retention:     environment.LogRetention,
+		RemovalPolicy: environment.RemovalPolicy,
+	})
+
+	// Task Role and Definition
+	taskRole := awsiam.NewRole(s.Construct, jsii.String("RefactorTaskRole"), &awsiam.RoleProps{
+		AssumedBy: awsiam.NewServicePrincipal(jsii.String("ecs-tasks.amazonaws.com"), nil),
+	})
+
+	// Grant the ECS task role permissions to read the database secret
+	props.Database.CredentialsSecret.GrantRead(taskRole, nil)
+
+	// Grant the ECS task role permissions to read CloudFormation stack outputs
+	taskRole.AddToPolicy(awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+		Effect: awsiam.Effect_ALLOW,
+		Actions: jsii.Strings(
+			"cloudformation:DescribeStacks",
+			"cloudformation:DescribeStackResources",
+			"cloudformation:DescribeStackEvents",
+		),
+		Resources: jsii.Strings(
+			fmt.Sprintf("arn:%s:cloudformation:%s:%s:stack/%s/*", partition, region, account, outputsStackName),
+		),
+	}))
+
+	// Grant permissions to read the application secrets
+	taskRole.AddToPolicy(awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+		Effect: awsiam.Effect_ALLOW,
+		Actions: jsii.Strings(
+			"secretsmanager:GetSecretValue",
+			"secretsmanager:DescribeSecret",
+		),
+		Resources: jsii.Strings(
+			fmt.Sprintf("arn:%s:secretsmanager:%s:%s:secret:%s/*", partition, region, account, naming.ParameterPath()),
+		),
+	}))
+
+	// Grant permissions to access Parameter Store for configuration
+	taskRole.AddToPolicy(awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+		Effect: awsiam.Effect_ALLOW,
+		Actions: jsii.Strings(
+			"ssm:GetParameter",
+			"ssm:GetParameters",
+			"ssm:GetParametersByPath",
+		),
+		Resources: jsii.Strings(
+			fmt.Sprintf("arn:%s:ssm:%s:%s:parameter%s/*", partition, region, account, naming.ParameterPath()),
+		),
+	}))
+
+	// Apply removal policy to ECS task role for clean deletion
+	taskRole.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	taskDef := awsecs.NewFargateTaskDefinition(s.Construct, jsii.String("RefactorTaskDef"), &awsecs.FargateTaskDefinitionProps{
+		Cpu:            jsii.Number(environment.TaskCPU),
+		MemoryLimitMiB: jsii.Number(environment.TaskMemoryMiB),
+		TaskRole:       taskRole,
+	})
+
+	// Apply removal policy to Fargate task definition for clean deletion
+	taskDef.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	// ECR Repository
+	ecrRepo := awsecr.NewRepository(s.Construct, jsii.String("RefactorEcrRepo"), &awsecr.RepositoryProps{
+		RepositoryName: jsii.String(naming.Name("ecr-repo")),
+		RemovalPolicy:  environment.statefulRemovalPolicy(),
+		EmptyOnDelete:  jsii.Bool(environment.autoDeleteOnRemoval()), // Automatically delete images when destroying the stack
+	})
+
+	// GitHub token the backend pushes branches with; CloudFormation generates a random value, replace it after
+	// the first deploy
+	gitTokenSecret := awssecretsmanager.NewSecret(s.Construct, jsii.String("GitTokenSecret"), &awssecretsmanager.SecretProps{
+		SecretName:    jsii.String(naming.ParameterPath("backend", "git-token")),
+		Description:   jsii.String("GitHub token used by the backend"),
+		RemovalPolicy: environment.statefulRemovalPolicy(),
+	})
+
+	// Container Definition
+	container := taskDef.AddContainer(jsii.String("RefactorContainer"), &awsecs.ContainerDefinitionOptions{
+		Image: awsecs.ContainerImage_FromEcrRepository(ecrRepo, jsii.String("latest")),
+		Logging: awsecs.LogDrivers_AwsLogs(&awsecs.AwsLogDriverProps{
+			StreamPrefix: jsii.String("refactor"),
+			LogGroup:     logGroup,
+		}),
+		Environment: &map[string]*string{
+			// Git configuration
+			"GIT_AUTHOR": jsii.String("CodeRefactorBot"),
+			"GIT_EMAIL":  jsii.String("bot@code-refactor.example.com"),
+
+			// AI Configuration - Add these new variables
+			"AI_DEFAULT_PROVIDER": jsii.String("bedrock"),
+			"AI_LOCAL_ENABLED":    jsii.String("false"),
+
+			// Bedrock RDS Configuration - Fix the naming to match your Go app's envconfig tags
+			"AI_BEDROCK_RDS_POSTGRES_CREDENTIALS_SECRET_ARN":   props.Database.CredentialsSecret.SecretArn(),
+			"AI_BEDROCK_RDS_POSTGRES_INSTANCE_ARN":             props.Database.Cluster.ClusterArn(),
+			"AI_BEDROCK_RDS_POSTGRES_READER_ENDPOINT":          jsii.String(props.Database.ReaderEndpoint),
+			"AI_BEDROCK_RDS_POSTGRES_DATABASE_NAME":            jsii.String(props.Database.DatabaseName),
+			"AI_BEDROCK_RDS_POSTGRES_TABLE_NAME":               jsii.String(vectorTableName),
+			"AI_BEDROCK_RDS_POSTGRES_SCHEMA_ENSURE_LAMBDA_ARN": props.Database.MigrationLambda.FunctionArn(),
+			"AI_BEDROCK_REGION":                                jsii.String(region),
+
+			// Bedrock AI Configuration - Populate with actual values from created resources
+			"AI_BEDROCK_KNOWLEDGE_BASE_SERVICE_ROLE_ARN": props.Bedrock.KnowledgeBaseRole.RoleArn(),
+			"AI_BEDROCK_AGENT_SERVICE_ROLE_ARN":          props.Bedrock.AgentRole.RoleArn(),
+			"AI_BEDROCK_S3_BUCKET_NAME":                  jsii.String(props.KnowledgeBase.BucketName),
+
+			// Cognito configuration - Populate with actual values from created resources
+			"COGNITO_USER_POOL_ID": jsii.String(props.Users.UserPoolID),
+			"COGNITO_CLIENT_ID":    jsii.String(props.Users.ClientID),
+			"COGNITO_REGION":       jsii.String(region),
+
+			// Metrics configuration
+			"METRICS_NAMESPACE":    jsii.String("CodeRefactorTool/API"),
+			"METRICS_REGION":       jsii.String(region),
+			"METRICS_SERVICE_NAME": jsii.String("code-refactor-api"),
+			"METRICS_ENABLED":      jsii.String("true"),
+
+			// Application configuration
+			"TIMEOUT_SECONDS": jsii.String("180"),
+			"LOG_LEVEL":       jsii.String("info"),
+		},
+		Secrets: &map[string]awsecs.Secret{
+			"GIT_TOKEN": awsecs.Secret_FromSecretsManager(gitTokenSecret, nil),
+		},
+	})
+
+	container.AddPortMappings(&awsecs.PortMapping{
+		ContainerPort: jsii.Number(8080),
+	})
+
+	s.Cluster = cluster
+	s.TaskDef = taskDef
+	s.EcrRepo = ecrRepo
+	s.LogGroup = logGroup
+}
+
+// createService creates the load balancer and the Fargate service registered behind it
+func (s *RefactorService) createService(props *RefactorServiceProps, environment *EnvironmentConfig) {
+	internal := props.LoadBalancer.Internal
+	subnets := workloadSubnetsOrDefault(props.VpcSubnets)
+	loadBalancerSubnets := &awsec2.SubnetSelection{
+		SubnetType: awsec2.SubnetType_PUBLIC, // Use public subnets for an internet-facing ALB
+	}
+	if internal {
+		loadBalancerSubnets = subnets
+	}
+
+	// Create Application Load Balancer; an internet-facing ALB is reached by API Gateway directly, an internal one
+	// through the VPC link target
+	loadBalancerProps := &awselasticloadbalancingv2.ApplicationLoadBalancerProps{
+		Vpc:            props.Vpc,
+		InternetFacing: jsii.Bool(!internal),
+		VpcSubnets:     loadBalancerSubnets,
+	}
+	// An open listener on a dual-stack load balancer also admits ::/0
+	if props.DualStack {
+		loadBalancerProps.IpAddressType = awselasticloadbalancingv2.IpAddressType_DUAL_STACK
+	}
+	loadBalancer := awselasticloadbalancingv2.NewApplicationLoadBalancer(s.Construct, jsii.String("CodeRefactorALB"), loadBalancerProps)
+
+	// Apply removal policy for clean deletion
+	loadBalancer.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	if props.AccessLogs != nil {
+		loadBalancer.LogAccessLogs(props.AccessLogs, jsii.String("alb"))
+	}
+
+	// Create Target Group for ECS Service
+	targetGroup := awselasticloadbalancingv2.NewApplicationTargetGroup(s.Construct, jsii.String("CodeRefactorTargetGroup"), &awselasticloadbalancingv2.ApplicationTargetGroupProps{
+		Port:       jsii.Number(8080),
+		Protocol:   awselasticloadbalancingv2.ApplicationProtocol_HTTP,
+		Vpc:        props.Vpc,
+		TargetType: awselasticloadbalancingv2.TargetType_IP,
+		HealthCheck: &awselasticloadbalancingv2.HealthCheck{
+			Path:                    jsii.String("/health"),
+			HealthyHttpCodes:        jsii.String("200"),
+			HealthyThresholdCount:   jsii.Number(2),
+			UnhealthyThresholdCount: jsii.Number(3),
+			Timeout:                 awscdk.Duration_Seconds(jsii.Number(5)),
+			Interval:                awscdk.Duration_Seconds(jsii.Number(30)),
+		},
+	})
+
+	// Create Security Group for ECS Service
+	ecsServiceSG := awsec2.NewSecurityGroup(s.Construct, jsii.String("EcsServiceSG"), &awsec2.SecurityGroupProps{
+		Vpc:                  props.Vpc,
+		Description:          jsii.String("Allow outbound connections from ECS service to RDS and other AWS services"),
+		AllowAllOutbound:     jsii.Bool(true),
+		AllowAllIpv6Outbound: jsii.Bool(props.DualStack),
+	})
+
+	// Apply removal policy for clean deletion
+	ecsServiceSG.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	// Create ECS Service
+	// Start with 0 desired count to avoid chicken-and-egg problem with ECR image
+	// This will be scaled up after the first image is pushed via GitHub Actions
+	service := awsecs.NewFargateService(s.Construct, jsii.String("CodeRefactorService"), &awsecs.FargateServiceProps{
+		Cluster:        s.Cluster,
+		TaskDefinition: s.TaskDef.(awsecs.TaskDefinition),
+		DesiredCount:   jsii.Number(environment.DesiredCount),
+		VpcSubnets:     subnets,
+		AssignPublicIp: props.Vpc.SelectSubnets(subnets).HasPublic, // Required for tasks in public subnets without NAT Gateway
+		SecurityGroups: &[]awsec2.ISecurityGroup{ecsServiceSG},
+	})
+
+	// Apply removal policy for clean deletion
+	service.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	// Attach the ECS service to the target group
+	service.AttachToApplicationTargetGroup(targetGroup)
+
+	// Allow ECS service to connect to the RDS database; AllowTo keeps the rule next to the service when the
+	// database lives in another stack
+	ecsServiceSG.Connections().AllowTo(props.Database.Cluster, awsec2.Port_Tcp(jsii.Number(5432)), jsii.String("Allow ECS service to connect to RDS"))
+
+	s.Service = service
+	s.LoadBalancer = loadBalancer
+	listener := s.createListener(props, environment, targetGroup)
+	if internal {
+		s.createVpcLinkTarget(props, environment, listener, subnets)
+		return
+	}
+	s.OriginVerification = newOriginVerification(s.Construct, listener, targetGroup, namingOrDefault(props.Naming), environment)
+}
+
+// createListener creates the listener API Gateway calls, which serves HTTPS when it is configured
+func (s *RefactorService) createListener(props *RefactorServiceProps, environment *EnvironmentConfig, targetGroup awselasticloadbalancingv2.IApplicationTargetGroup) awselasticloadbalancingv2.ApplicationListener {
+	internal := props.LoadBalancer.Internal
+	listenerProps := &awselasticloadbalancingv2.BaseApplicationListenerProps{
+		Port:     jsii.Number(props.LoadBalancer.listenerPort()),
+		Protocol: props.LoadBalancer.listenerProtocol(),
+	}
+	if internal {
+		// An internal ALB only admits the VPC link target
+		listenerProps.Open = jsii.Bool(false)
+		listenerProps.DefaultTargetGroups = &[]awselasticloadbalancingv2.IApplicationTargetGroup{targetGroup}
+	} else {
+		// API Gateway calls the internet-facing ALB from public addresses without a managed prefix list, so the
+		// listener stays open and instead rejects requests without the origin header
+		listenerProps.DefaultAction = awselasticloadbalancingv2.ListenerAction_FixedResponse(jsii.Number(403), &awselasticloadbalancingv2.FixedResponseOptions{
+			ContentType: jsii.String("text/plain"),
+			MessageBody: jsii.String("Forbidden"),
+		})
+	}
+
+	if props.LoadBalancer.HTTPS == nil {
+		return s.LoadBalancer.AddListener(jsii.String("CodeRefactorListener"), listenerProps)
+	}
+	s.addHTTPS(props.LoadBalancer.HTTPS, listenerProps, !internal, environment)
+	return s.LoadBalancer.AddListener(jsii.String("CodeRefactorHttpsListener"), listenerProps)
+}
+
+// createVpcLinkTarget creates the internal Network Load Balancer that an API Gateway VPC link forwards requests
+// to, with the Application Load Balancer as its only target
+func (s *RefactorService) createVpcLinkTarget(props *RefactorServiceProps, environment *EnvironmentConfig, listener awselasticloadbalancingv2.ApplicationListener, subnets *awsec2.SubnetSelection) {
+	// VPC link traffic arrives over PrivateLink, which the security group does not filter; the group only
+	// identifies the NLB to the ALB
+	securityGroup := awsec2.NewSecurityGroup(s.Construct, jsii.String("VpcLinkTargetSG"), &awsec2.SecurityGroupProps{
+		Vpc:              props.Vpc,
+		Description:      jsii.String("Identify the VPC link target to the internal load balancer"),
+		AllowAllOutbound: jsii.Bool(false),
+	})
+	securityGroup.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	networkLoadBalancer := awselasticloadbalancingv2.NewNetworkLoadBalancer(s.Construct, jsii.String("VpcLinkTarget"), &awselasticloadbalancingv2.NetworkLoadBalancerProps{
+		Vpc:            props.Vpc,
+		InternetFacing: jsii.Bool(false),
+		VpcSubnets:     subnets,
+		SecurityGroups: &[]awsec2.ISecurityGroup{securityGroup},
+		EnforceSecurityGroupInboundRulesOnPrivateLinkTraffic: jsii.Bool(false),
+	})
+	networkLoadBalancer.ApplyRemovalPolicy(environment.RemovalPolicy)
+	SuppressSecurityRule(networkLoadBalancer, SecurityRuleMissingAccessLogs, "NLB access logs only cover TLS listeners; the ALB behind this TCP listener logs every request.")
+
+	// The NLB passes TLS through to the ALB, which holds the certificate
+	port := props.LoadBalancer.listenerPort()
+	healthCheckProtocol := awselasticloadbalancingv2.Protocol_HTTP
+	if props.LoadBalancer.HTTPS != nil {
+		healthCheckProtocol = awselasticloadbalancingv2.Protocol_HTTPS
+	}
+	networkLoadBalancer.AddListener(jsii.String("VpcLinkListener"), &awselasticloadbalancingv2.BaseNetworkListenerProps{
+		Port:     jsii.Number(port),
+		Protocol: awselasticloadbalancingv2.Protocol_TCP,
+		DefaultTargetGroups: &[]awselasticloadbalancingv2.INetworkTargetGroup{
+			awselasticloadbalancingv2.NewNetworkTargetGroup(s.Construct, jsii.String("VpcLinkTargetGroup"), &awselasticloadbalancingv2.NetworkTargetGroupProps{
+				Port:       jsii.Number(port),
+				Protocol:   awselasticloadbalancingv2.Protocol_TCP,
+				Vpc:        props.Vpc,
+				TargetType: awselasticloadbalancingv2.TargetType_ALB,
+				Targets:    &[]awselasticloadbalancingv2.INetworkLoadBalancerTarget{awselasticloadbalancingv2targets.NewAlbListenerTarget(listener)},
+				HealthCheck: &awselasticloadbalancingv2.HealthCheck{
+					Protocol: healthCheckProtocol,
+					Path:     jsii.String("/health"),
+				},
+			}),
+		},
+	})
+
+	// The ALB only accepts traffic forwarded by the NLB
+	s.LoadBalancer.Connections().AllowFrom(networkLoadBalancer, awsec2.Port_Tcp(jsii.Number(port)), jsii.String("Allow the VPC link target"))
+
+	s.VpcLinkTarget = networkLoadBalancer
+}
+
+// loadBalancerOrDefault returns the given load balancer settings, or the zero value selecting an internet-facing
+// load balancer when it is nil.
+func loadBalancerOrDefault(loadBalancer *LoadBalancerConfig) LoadBalancerConfig {
+	if loadBalancer == nil {
+		return LoadBalancerConfig{}
+	}
+	return *loadBalancer
+}
@@ -0,0 +1,380 @@
+package stack
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestRefactorService_ComposesWithOtherConstructs(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("ServiceStack"), nil)
+	network := NewNetwork(stack, "Network", &NetworkProps{})
+	knowledgeBase := NewKnowledgeBaseBucket(stack, "KnowledgeBase", &KnowledgeBaseBucketProps{})
+	database := NewVectorDatabase(stack, "Database", &VectorDatabaseProps{Vpc: network.Vpc})
+	users := NewUserDirectory(stack, "Users", &UserDirectoryProps{})
+	bedrock := NewBedrockRoles(stack, "Bedrock", &BedrockRolesProps{
+		KnowledgeBaseBucket: knowledgeBase.Bucket,
+		Database:            database,
+	})
+
+	// Act
+	service := NewRefactorService(stack, "Service", &RefactorServiceProps{
+		Vpc:             network.Vpc,
+		Database:        database,
+		KnowledgeBase:   knowledgeBase,
+		Bedrock:         bedrock,
+		Users:           users,
+		VectorTableName: "embeddings",
+	})
+	template := assertions.Template_FromStack(stack, nil)
+
+	// Assert
+	t.Run("runs the service behind the load balancer", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::ECS::Service"), map[string]interface{}{
+			"DesiredCount": DevEnvironment().DesiredCount,
+			"LaunchType":   "FARGATE",
+			"LoadBalancers": []interface{}{
+				map[string]interface{}{"ContainerPort": 8080},
+			},
+		})
+		template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::Listener"), map[string]interface{}{
+			"Port":     80,
+			"Protocol": "HTTP",
+		})
+	})
+
+	t.Run("passes the vector table to the container", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::ECS::TaskDefinition"), map[string]interface{}{
+			"ContainerDefinitions": []interface{}{
+				map[string]interface{}{
+					"Environment": assertions.Match_ArrayWith(&[]interface{}{
+						map[string]interface{}{"Name": "AI_BEDROCK_RDS_POSTGRES_TABLE_NAME", "Value": "embeddings"},
+					}),
+				},
+			},
+		})
+	})
+
+	t.Run("scopes the task role to the stack's partition", func(_ *testing.T) {
+		for _, service := range []string{":cloudformation:", ":secretsmanager:", ":ssm:"} {
+			template.HasResourceProperties(jsii.String("AWS::IAM::Policy"), map[string]interface{}{
+				"PolicyDocument": map[string]interface{}{
+					"Statement": assertions.Match_ArrayWith(&[]interface{}{
+						assertions.Match_ObjectLike(&map[string]interface{}{
+							"Resource": map[string]interface{}{
+								"Fn::Join": []interface{}{"", assertions.Match_ArrayWith(&[]interface{}{"arn:", map[string]interface{}{"Ref": "AWS::Partition"}, service})},
+							},
+						}),
+					}),
+				},
+			})
+		}
+	})
+
+	t.Run("exposes its resources", func(t *testing.T) {
+		if service.LoadBalancer == nil || service.Service == nil || service.EcrRepo == nil {
+			t.Error("expected load balancer, service and repository to be set")
+		}
+	})
+}
+
+func TestAppStack_InternalLoadBalancer(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := NewAppStack(app, "TestStack", &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Region: jsii.String("us-east-1"),
+			},
+		},
+		Networking:   &NetworkConfig{Egress: NetworkEgressNATGateway},
+		LoadBalancer: &LoadBalancerConfig{Internal: true},
+	})
+
+	// Act
+	template := assertions.Template_FromStack(stack.Stack, nil)
+
+	// Assert
+	t.Run("no load balancer is internet-facing", func(t *testing.T) {
+		internetFacing := template.FindResources(jsii.String("AWS::ElasticLoadBalancingV2::LoadBalancer"), map[string]interface{}{
+			"Properties": map[string]interface{}{"Scheme": "internet-facing"},
+		})
+		if len(*internetFacing) != 0 {
+			t.Errorf("found %d internet-facing load balancers", len(*internetFacing))
+		}
+		template.ResourcePropertiesCountIs(jsii.String("AWS::ElasticLoadBalancingV2::LoadBalancer"), map[string]interface{}{
+			"Scheme": "internal",
+		}, jsii.Number(2))
+	})
+
+	t.Run("no listener is open to the internet", func(t *testing.T) {
+		for id, group := range *template.FindResources(jsii.String("AWS::EC2::SecurityGroup"), nil) {
+			properties, _ := (*group)["Properties"].(map[string]interface{})
+			ingress, _ := properties["SecurityGroupIngress"].([]interface{})
+			for _, rule := range ingress {
+				rule, _ := rule.(map[string]interface{})
+				if rule["CidrIp"] == "0.0.0.0/0" || rule["CidrIpv6"] == "::/0" {
+					t.Errorf("security group %s admits %v", id, rule)
+				}
+			}
+		}
+	})
+
+	t.Run("only the load balancer reaches the tasks", func(t *testing.T) {
+		rules := template.FindResources(jsii.String("AWS::EC2::SecurityGroupIngress"), map[string]interface{}{
+			"Properties": map[string]interface{}{"ToPort": 8080},
+		})
+		if len(*rules) != 1 {
+			t.Fatalf("found %d ingress rules to the tasks, want 1", len(*rules))
+		}
+		for _, rule := range *rules {
+			properties, _ := (*rule)["Properties"].(map[string]interface{})
+			source, _ := properties["SourceSecurityGroupId"].(map[string]interface{})
+			attribute, _ := source["Fn::GetAtt"].([]interface{})
+			if len(attribute) == 0 || !strings.Contains(fmt.Sprint(attribute[0]), "CodeRefactorALB") {
+				t.Errorf("tasks admit %v, want the load balancer", properties["SourceSecurityGroupId"])
+			}
+		}
+	})
+
+	t.Run("the VPC link needs no origin secret", func(_ *testing.T) {
+		template.ResourceCountIs(jsii.String("AWS::SecretsManager::RotationSchedule"), jsii.Number(0))
+		template.ResourceCountIs(jsii.String("AWS::ElasticLoadBalancingV2::ListenerRule"), jsii.Number(0))
+	})
+
+	t.Run("API Gateway proxies through a VPC link", func(_ *testing.T) {
+		template.ResourceCountIs(jsii.String("AWS::ApiGateway::VpcLink"), jsii.Number(1))
+		template.HasResourceProperties(jsii.String("AWS::ApiGateway::Method"), map[string]interface{}{
+			"Integration": map[string]interface{}{
+				"Type":           "HTTP_PROXY",
+				"ConnectionType": "VPC_LINK",
+			},
+		})
+	})
+}
+
+func TestAppStack_OriginVerification(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := NewAppStack(app, "TestStack", &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Region: jsii.String("us-east-1"),
+			},
+		},
+	})
+
+	// Act
+	template := assertions.Template_FromStack(stack.Stack, nil)
+
+	// Assert
+	t.Run("the listener rejects requests by default", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::Listener"), map[string]interface{}{
+			"DefaultActions": []interface{}{
+				map[string]interface{}{
+					"Type": "fixed-response",
+					"FixedResponseConfig": map[string]interface{}{
+						"StatusCode": "403",
+					},
+				},
+			},
+		})
+	})
+
+	t.Run("only requests carrying the origin header reach the service", func(_ *testing.T) {
+		template.ResourceCountIs(jsii.String("AWS::ElasticLoadBalancingV2::ListenerRule"), jsii.Number(1))
+		template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::ListenerRule"), map[string]interface{}{
+			"Conditions": []interface{}{
+				map[string]interface{}{
+					"Field": "http-header",
+					"HttpHeaderConfig": map[string]interface{}{
+						"HttpHeaderName": OriginVerifyHeader,
+					},
+				},
+			},
+			"Actions": []interface{}{
+				assertions.Match_ObjectLike(&map[string]interface{}{"Type": "forward"}),
+			},
+		})
+	})
+
+	t.Run("API Gateway injects the origin header", func(t *testing.T) {
+		methods := template.FindResources(jsii.String("AWS::ApiGateway::Method"), map[string]interface{}{
+			"Properties": map[string]interface{}{
+				"Integration": map[string]interface{}{"Type": "HTTP_PROXY"},
+			},
+		})
+		if len(*methods) == 0 {
+			t.Fatal("found no proxy integrations")
+		}
+		for id, method := range *methods {
+			properties, _ := (*method)["Properties"].(map[string]interface{})
+			integration, _ := properties["Integration"].(map[string]interface{})
+			parameters, _ := integration["RequestParameters"].(map[string]interface{})
+			if _, ok := parameters["integration.request.header."+OriginVerifyHeader]; !ok {
+				t.Errorf("method %s does not send %s", id, OriginVerifyHeader)
+			}
+		}
+	})
+
+	t.Run("the rotation updates the API in the stack's partition", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::IAM::Policy"), map[string]interface{}{
+			"PolicyDocument": map[string]interface{}{
+				"Statement": assertions.Match_ArrayWith(&[]interface{}{
+					assertions.Match_ObjectLike(&map[string]interface{}{
+						"Action": []interface{}{"apigateway:GET", "apigateway:PATCH", "apigateway:POST"},
+						"Resource": assertions.Match_ArrayWith(&[]interface{}{
+							map[string]interface{}{
+								"Fn::Join": []interface{}{"", assertions.Match_ArrayWith(&[]interface{}{"arn:", map[string]interface{}{"Ref": "AWS::Partition"}, ":apigateway:us-east-1::/restapis/"})},
+							},
+						}),
+					}),
+				}),
+			},
+		})
+	})
+
+	t.Run("the origin secret rotates", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::SecretsManager::RotationSchedule"), map[string]interface{}{
+			"RotationRules": map[string]interface{}{
+				"ScheduleExpression": fmt.Sprintf("rate(%d days)", originSecretRotationDays),
+			},
+		})
+	})
+}
+
+func TestAppStack_HTTPS(t *testing.T) {
+	newTemplate := func(loadBalancer *LoadBalancerConfig) assertions.Template {
+		app := awscdk.NewApp(nil)
+		stack := NewAppStack(app, "TestStack", &AppStackProps{
+			StackProps: awscdk.StackProps{
+				Env: &awscdk.Environment{
+					Region: jsii.String("us-east-1"),
+				},
+			},
+			Networking:   &NetworkConfig{Egress: NetworkEgressNATGateway},
+			LoadBalancer: loadBalancer,
+		})
+		return assertions.Template_FromStack(stack.Stack, nil)
+	}
+	redirect := map[string]interface{}{
+		"Port":     80,
+		"Protocol": "HTTP",
+		"DefaultActions": []interface{}{
+			map[string]interface{}{
+				"Type": "redirect",
+				"RedirectConfig": map[string]interface{}{
+					"Protocol":   "HTTPS",
+					"Port":       "443",
+					"StatusCode": "HTTP_301",
+				},
+			},
+		},
+	}
+
+	t.Run("internet-facing load balancer with a requested certificate", func(t *testing.T) {
+		// Arrange
+		https := &HTTPSConfig{
+			DomainName: "origin.example.com",
+			HostedZone: &HostedZoneConfig{ID: "Z0123456789ABCDEFGHIJ", Name: "example.com"},
+		}
+
+		// Act
+		template := newTemplate(&LoadBalancerConfig{HTTPS: https})
+
+		// Assert
+		t.Run("requests a DNS validated certificate", func(_ *testing.T) {
+			template.HasResourceProperties(jsii.String("AWS::CertificateManager::Certificate"), map[string]interface{}{
+				"DomainName":       "origin.example.com",
+				"ValidationMethod": "DNS",
+				"DomainValidationOptions": []interface{}{
+					map[string]interface{}{"HostedZoneId": "Z0123456789ABCDEFGHIJ"},
+				},
+			})
+		})
+
+		t.Run("serves HTTPS with a TLS 1.3 policy", func(_ *testing.T) {
+			template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::Listener"), map[string]interface{}{
+				"Port":      443,
+				"Protocol":  "HTTPS",
+				"SslPolicy": assertions.Match_StringLikeRegexp(jsii.String("TLS13")),
+				"DefaultActions": []interface{}{
+					assertions.Match_ObjectLike(&map[string]interface{}{"Type": "fixed-response"}),
+				},
+			})
+		})
+
+		t.Run("redirects HTTP to HTTPS", func(_ *testing.T) {
+			template.ResourceCountIs(jsii.String("AWS::ElasticLoadBalancingV2::Listener"), jsii.Number(2))
+			template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::Listener"), redirect)
+		})
+
+		t.Run("checks the origin header on the HTTPS listener", func(t *testing.T) {
+			rules := template.FindResources(jsii.String("AWS::ElasticLoadBalancingV2::ListenerRule"), nil)
+			for id, rule := range *rules {
+				properties, _ := (*rule)["Properties"].(map[string]interface{})
+				listener, _ := properties["ListenerArn"].(map[string]interface{})
+				if !strings.Contains(fmt.Sprint(listener["Ref"]), "CodeRefactorHttpsListener") {
+					t.Errorf("rule %s is attached to %v", id, properties["ListenerArn"])
+				}
+			}
+		})
+
+		t.Run("points the domain name at the load balancer", func(_ *testing.T) {
+			template.HasResourceProperties(jsii.String("AWS::Route53::RecordSet"), map[string]interface{}{
+				"Name":         "origin.example.com.",
+				"Type":         "A",
+				"HostedZoneId": "Z0123456789ABCDEFGHIJ",
+			})
+		})
+
+		t.Run("API Gateway calls the domain name over HTTPS", func(_ *testing.T) {
+			template.HasResourceProperties(jsii.String("AWS::ApiGateway::Method"), map[string]interface{}{
+				"Integration": map[string]interface{}{
+					"Type": "HTTP_PROXY",
+					"Uri":  "https://origin.example.com",
+				},
+			})
+		})
+	})
+
+	t.Run("internal load balancer with an imported certificate", func(_ *testing.T) {
+		// Arrange
+		certificateArn := "arn:aws:acm:us-east-1:123456789012:certificate/0a1b2c3d-4e5f-6a7b-8c9d-0e1f2a3b4c5d"
+		https := &HTTPSConfig{DomainName: "origin.internal.example.com", CertificateArn: certificateArn}
+
+		// Act
+		template := newTemplate(&LoadBalancerConfig{Internal: true, HTTPS: https})
+
+		// Assert
+		template.ResourceCountIs(jsii.String("AWS::CertificateManager::Certificate"), jsii.Number(0))
+		template.ResourceCountIs(jsii.String("AWS::Route53::RecordSet"), jsii.Number(0))
+		template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::Listener"), map[string]interface{}{
+			"Port":         443,
+			"Protocol":     "HTTPS",
+			"Certificates": []interface{}{map[string]interface{}{"CertificateArn": certificateArn}},
+		})
+		template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::Listener"), redirect)
+		template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::Listener"), map[string]interface{}{
+			"Port":     443,
+			"Protocol": "TCP",
+		})
+		template.HasResourceProperties(jsii.String("AWS::ElasticLoadBalancingV2::TargetGroup"), map[string]interface{}{
+			"TargetType":          "alb",
+			"Port":                443,
+			"HealthCheckProtocol": "HTTPS",
+		})
+		template.HasResourceProperties(jsii.String("AWS::ApiGateway::Method"), map[string]interface{}{
+			"Integration": map[string]interface{}{
+				"ConnectionType": "VPC_LINK",
+				"Uri":            "https://origin.internal.example.com",
+			},
+		})
+	})
+}
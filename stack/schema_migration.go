@@ -0,0 +1,69 @@
+package stack
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/customresources"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// schemaVersionAttribute is the custom resource attribute holding the schema version the migration applied.
+const schemaVersionAttribute = "SchemaVersion"
+
+// MigrationConfig selects how far the migration Lambda migrates the vector tables through the numbered migrations
+// bundled in rds_schema_lambda/migrations.
+type MigrationConfig struct {
+	// TargetVersion is the migration version to migrate up or down to. Zero migrates to the latest version.
+	TargetVersion int
+
+	// DryRun logs the migrations that would run without applying them.
+	DryRun bool
+}
+
+// targetVersion is the TargetVersion property of the schema migration.
+func (m MigrationConfig) targetVersion() string {
+	if m.TargetVersion == 0 {
+		return "latest"
+	}
+	return strconv.Itoa(m.TargetVersion)
+}
+
+// migrationsOrDefault returns the migration settings, migrating to the latest version when they are unset.
+func migrationsOrDefault(migrations *MigrationConfig) MigrationConfig {
+	if migrations == nil {
+		return MigrationConfig{}
+	}
+	return *migrations
+}
+
+// addSchemaMigration runs the migration Lambda from CloudFormation whenever the schema version, database, vector
+// stores or migration settings change. The Lambda reads them only from the resource properties. A failed migration
+// fails the deployment, and the rollback runs the Lambda again with the previous properties, which it accepts
+// because the migration is idempotent. Deleting the resource leaves the schema alone.
+func (d *VectorDatabase) addSchemaMigration(props *VectorDatabaseProps) {
+	schemaVersion := props.SchemaVersion
+	if schemaVersion == "" {
+		schemaVersion = SchemaVersion
+	}
+
+	provider := customresources.NewProvider(d.Construct, jsii.String("SchemaMigrationProvider"), &customresources.ProviderProps{
+		OnEventHandler: d.MigrationLambda,
+	})
+	migration := awscdk.NewCustomResource(d.Construct, jsii.String("SchemaMigration"), &awscdk.CustomResourceProps{
+		ServiceToken: provider.ServiceToken(),
+		ResourceType: jsii.String("Custom::SchemaMigration"),
+		Properties: &map[string]interface{}{
+			"SchemaVersion": schemaVersion,
+			"Database":      d.DatabaseName,
+			"VectorStores":  vectorStoreParameters(d.VectorStores),
+			"TargetVersion": props.Migrations.targetVersion(),
+			"DryRun":        strconv.FormatBool(props.Migrations.DryRun),
+		},
+	})
+	// The writer instance must accept connections before the migration runs
+	migration.Node().AddDependency(d.Cluster)
+
+	d.SchemaMigration = migration
+	d.SchemaVersion = *migration.GetAttString(jsii.String(schemaVersionAttribute))
+}
@@ -0,0 +1,372 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsapigateway"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudfront"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsecs"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awselasticloadbalancingv2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsrds"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Rule IDs checked by SecurityPolicy. Pass them to SuppressSecurityRule to exempt a resource.
+const (
+	// SecurityRuleWildcardIAMResource flags IAM statements allowing actions on every resource.
+	SecurityRuleWildcardIAMResource = "IAM-WILDCARD-RESOURCE"
+	// SecurityRulePublicDatabaseSubnet flags database subnet groups containing public subnets.
+	SecurityRulePublicDatabaseSubnet = "RDS-PUBLIC-SUBNET"
+	// SecurityRuleUnencryptedStorage flags buckets and databases without encryption at rest.
+	SecurityRuleUnencryptedStorage = "STORAGE-UNENCRYPTED"
+	// SecurityRuleMissingAccessLogs flags buckets, load balancers, distributions and API stages without access logs.
+	SecurityRuleMissingAccessLogs = "ACCESS-LOGS-MISSING"
+	// SecurityRulePlaintextSecretEnv flags container and function environment variables holding literal secrets.
+	SecurityRulePlaintextSecretEnv = "ENV-PLAINTEXT-SECRET"
+
+	// SecurityReportFile is the report file name, written next to the synthesized templates.
+	SecurityReportFile = "security-report.json"
+
+	securitySuppressionMetadata = "security-policy:suppression"
+)
+
+var (
+	// wildcardOnlyActions do not support resource-level permissions, so granting them on "*" is not a finding.
+	wildcardOnlyActions = map[string]bool{
+		"cognito-idp:DescribeUserPoolDomain": true,
+		"ecr:GetAuthorizationToken":          true,
+		"secretsmanager:GetRandomPassword":   true,
+	}
+
+	secretNamePattern    = regexp.MustCompile(`(?i)(SECRET|PASSWORD|PASSWD|TOKEN|API_?KEY|PRIVATE_?KEY)`)
+	referenceNamePattern = regexp.MustCompile(`(?i)_(ARN|ID|NAME)$`)
+
+	securityRules = []securityRule{
+		{SecurityRuleWildcardIAMResource, "IAM statements must name the resources they allow", checkWildcardIAMResource},
+		{SecurityRulePublicDatabaseSubnet, "Databases must not be placed in public subnets", checkPublicDatabaseSubnet},
+		{SecurityRuleUnencryptedStorage, "Buckets and databases must be encrypted at rest", checkUnencryptedStorage},
+		{SecurityRuleMissingAccessLogs, "Buckets, load balancers, distributions and API stages must write access logs", checkMissingAccessLogs},
+		{SecurityRulePlaintextSecretEnv, "Secrets must be injected from Secrets Manager, not set as literal environment variables", checkPlaintextSecretEnv},
+	}
+)
+
+// securityRule returns a message for each violation it finds in a resource.
+type securityRule struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	check       func(p *SecurityPolicy, resource awscdk.CfnResource) []string
+}
+
+// SecurityFinding is a rule violation on a single resource.
+type SecurityFinding struct {
+	Rule          string `json:"rule"`
+	Path          string `json:"path"`
+	ResourceType  string `json:"resourceType"`
+	Message       string `json:"message"`
+	Suppressed    bool   `json:"suppressed"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// SecurityPolicy is a read-only aspect checking every CloudFormation resource against the security rules.
+// Unsuppressed findings are reported as warnings, or as errors that fail synthesis when the policy is enforced.
+type SecurityPolicy struct {
+	enforce       bool
+	findings      []SecurityFinding
+	publicSubnets map[string]bool
+}
+
+// AddSecurityPolicy checks every resource under scope during synthesis and returns the policy holding the findings.
+func AddSecurityPolicy(scope constructs.IConstruct, enforce bool) *SecurityPolicy {
+	policy := &SecurityPolicy{enforce: enforce, findings: []SecurityFinding{}}
+	awscdk.Aspects_Of(scope).Add(policy, &awscdk.AspectOptions{Priority: awscdk.AspectPriority_READONLY()})
+	return policy
+}
+
+// SuppressSecurityRule exempts scope and every resource below it from a rule. The justification is mandatory
+// and is written to the report.
+func SuppressSecurityRule(scope constructs.IConstruct, rule, justification string) {
+	if strings.TrimSpace(justification) == "" {
+		awscdk.Annotations_Of(scope).AddError(jsii.String(fmt.Sprintf("suppressing %s requires a justification", rule)))
+		return
+	}
+	scope.Node().AddMetadata(jsii.String(securitySuppressionMetadata), map[string]interface{}{
+		"rule":          rule,
+		"justification": justification,
+	}, nil)
+}
+
+// Visit checks a CloudFormation resource against every rule.
+func (p *SecurityPolicy) Visit(node constructs.IConstruct) {
+	resource, ok := node.(awscdk.CfnResource)
+	if !ok {
+		return
+	}
+
+	for _, rule := range securityRules {
+		for _, message := range rule.check(p, resource) {
+			p.record(resource, rule.ID, message)
+		}
+	}
+}
+
+// Findings returns every finding, suppressed or not, in the order the resources were visited.
+func (p *SecurityPolicy) Findings() []SecurityFinding {
+	return p.findings
+}
+
+// Violations returns the findings that are not suppressed.
+func (p *SecurityPolicy) Violations() []SecurityFinding {
+	violations := []SecurityFinding{}
+	for _, finding := range p.findings {
+		if !finding.Suppressed {
+			violations = append(violations, finding)
+		}
+	}
+	return violations
+}
+
+// WriteReport writes the rules and findings as JSON to path.
+func (p *SecurityPolicy) WriteReport(path string) error {
+	content, err := json.MarshalIndent(map[string]interface{}{
+		"enforced": p.enforce,
+		"rules":    securityRules,
+		"findings": p.findings,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode security report: %w", err)
+	}
+
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write security report %s: %w", path, err)
+	}
+	return nil
+}
+
+// record stores a finding and annotates the resource unless the rule is suppressed.
+func (p *SecurityPolicy) record(resource awscdk.CfnResource, rule, message string) {
+	finding := SecurityFinding{
+		Rule:         rule,
+		Path:         *resource.Node().Path(),
+		ResourceType: *resource.CfnResourceType(),
+		Message:      message,
+	}
+	finding.Justification, finding.Suppressed = suppression(resource, rule)
+	p.findings = append(p.findings, finding)
+
+	if finding.Suppressed {
+		return
+	}
+	annotation := jsii.String(fmt.Sprintf("[%s] %s", rule, message))
+	if p.enforce {
+		awscdk.Annotations_Of(resource).AddError(annotation)
+	} else {
+		awscdk.Annotations_Of(resource).AddWarningV2(jsii.String("security-policy:"+rule), annotation)
+	}
+}
+
+// suppression returns the justification of a suppression of rule on the resource or any of its scopes.
+func suppression(resource constructs.IConstruct, rule string) (string, bool) {
+	for _, scope := range *resource.Node().Scopes() {
+		for _, entry := range *scope.Node().Metadata() {
+			data, _ := entry.Data.(map[string]interface{})
+			if *entry.Type == securitySuppressionMetadata && data["rule"] == rule {
+				justification, _ := data["justification"].(string)
+				return justification, true
+			}
+		}
+	}
+	return "", false
+}
+
+// isPublicSubnet reports whether subnetID is a public subnet of any VPC in the app.
+func (p *SecurityPolicy) isPublicSubnet(scope constructs.IConstruct, subnetID string) bool {
+	if p.publicSubnets == nil {
+		p.publicSubnets = map[string]bool{}
+		for _, node := range *scope.Node().Root().Node().FindAll(constructs.ConstructOrder_PREORDER) {
+			if vpc, ok := node.(awsec2.IVpc); ok {
+				for _, subnet := range *vpc.PublicSubnets() {
+					p.publicSubnets[*subnet.SubnetId()] = true
+				}
+			}
+		}
+	}
+	return p.publicSubnets[subnetID]
+}
+
+// checkWildcardIAMResource flags allow statements on "*" in policies and inline role policies.
+func checkWildcardIAMResource(_ *SecurityPolicy, resource awscdk.CfnResource) []string {
+	var documents []interface{}
+	switch typed := resource.(type) {
+	case awsiam.CfnPolicy:
+		documents = append(documents, resolve(resource, typed.PolicyDocument()))
+	case awsiam.CfnManagedPolicy:
+		documents = append(documents, resolve(resource, typed.PolicyDocument()))
+	case awsiam.CfnRole:
+		for _, policy := range asList(resolve(resource, typed.Policies())) {
+			documents = append(documents, asMap(policy)["policyDocument"])
+		}
+	}
+
+	var messages []string
+	for _, document := range documents {
+		for _, statement := range asList(asMap(document)["Statement"]) {
+			fields := asMap(statement)
+			if fields["Effect"] == "Allow" && containsValue(asList(fields["Resource"]), "*") && !onlyWildcardActions(asList(fields["Action"])) {
+				messages = append(messages, fmt.Sprintf("allows %v on every resource", fields["Action"]))
+			}
+		}
+	}
+	return messages
+}
+
+// onlyWildcardActions reports whether every action lacks resource-level permissions.
+func onlyWildcardActions(actions []interface{}) bool {
+	for _, action := range actions {
+		if name, _ := action.(string); !wildcardOnlyActions[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkPublicDatabaseSubnet flags database subnet groups containing a public subnet.
+func checkPublicDatabaseSubnet(p *SecurityPolicy, resource awscdk.CfnResource) []string {
+	subnetGroup, ok := resource.(awsrds.CfnDBSubnetGroup)
+	if !ok {
+		return nil
+	}
+
+	for _, subnetID := range *subnetGroup.SubnetIds() {
+		if p.isPublicSubnet(resource, *subnetID) {
+			return []string{"places the database in public subnets"}
+		}
+	}
+	return nil
+}
+
+// checkUnencryptedStorage flags buckets without default encryption and unencrypted database storage.
+func checkUnencryptedStorage(_ *SecurityPolicy, resource awscdk.CfnResource) []string {
+	var encrypted bool
+	switch typed := resource.(type) {
+	case awss3.CfnBucket:
+		encrypted = resolve(resource, typed.BucketEncryption()) != nil
+	case awsrds.CfnDBCluster:
+		encrypted = resolve(resource, typed.StorageEncrypted()) == true
+	case awsrds.CfnDBInstance:
+		encrypted = typed.DbClusterIdentifier() != nil || resolve(resource, typed.StorageEncrypted()) == true
+	default:
+		return nil
+	}
+
+	if !encrypted {
+		return []string{"is not encrypted at rest"}
+	}
+	return nil
+}
+
+// checkMissingAccessLogs flags resources serving requests without recording them.
+func checkMissingAccessLogs(_ *SecurityPolicy, resource awscdk.CfnResource) []string {
+	var logged bool
+	switch typed := resource.(type) {
+	case awss3.CfnBucket:
+		logged = resolve(resource, typed.LoggingConfiguration()) != nil
+	case awselasticloadbalancingv2.CfnLoadBalancer:
+		enabled := map[string]interface{}{"key": "access_logs.s3.enabled", "value": "true"}
+		logged = containsValue(asList(resolve(resource, typed.LoadBalancerAttributes())), enabled)
+	case awscloudfront.CfnDistribution:
+		logged = asMap(resolve(resource, typed.DistributionConfig()))["logging"] != nil
+	case awsapigateway.CfnStage:
+		logged = resolve(resource, typed.AccessLogSetting()) != nil
+	default:
+		return nil
+	}
+
+	if !logged {
+		return []string{"does not write access logs"}
+	}
+	return nil
+}
+
+// checkPlaintextSecretEnv flags secret-looking environment variables set to literal values.
+func checkPlaintextSecretEnv(_ *SecurityPolicy, resource awscdk.CfnResource) []string {
+	variables := map[string]interface{}{}
+	switch typed := resource.(type) {
+	case awsecs.CfnTaskDefinition:
+		for _, container := range asList(resolve(resource, typed.ContainerDefinitions())) {
+			for _, variable := range asList(asMap(container)["environment"]) {
+				fields := asMap(variable)
+				name, _ := fields["name"].(string)
+				variables[name] = fields["value"]
+			}
+		}
+	case awslambda.CfnFunction:
+		variables = asMap(asMap(resolve(resource, typed.Environment()))["variables"])
+	}
+
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var messages []string
+	for _, name := range names {
+		if _, literal := variables[name].(string); literal && secretNamePattern.MatchString(name) && !referenceNamePattern.MatchString(name) {
+			messages = append(messages, fmt.Sprintf("sets %s to a literal value", name))
+		}
+	}
+	return messages
+}
+
+// resolve resolves tokens in an L1 property to plain values and CloudFormation intrinsics. Unset properties
+// resolve to nil.
+func resolve(resource awscdk.CfnResource, value interface{}) interface{} {
+	if reflected := reflect.ValueOf(value); !reflected.IsValid() || (reflected.Kind() == reflect.Ptr && reflected.IsNil()) {
+		return nil
+	}
+	return awscdk.Stack_Of(resource).Resolve(value)
+}
+
+// asList returns value as a list, wrapping a single value.
+func asList(value interface{}) []interface{} {
+	switch typed := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return typed
+	default:
+		return []interface{}{typed}
+	}
+}
+
+// asMap returns value as a map, or an empty map when it is not one.
+func asMap(value interface{}) map[string]interface{} {
+	typed, ok := value.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{}
+	}
+	return typed
+}
+
+// containsValue reports whether list holds an element equal to want.
+func containsValue(list []interface{}, want interface{}) bool {
+	wanted, _ := json.Marshal(want)
+	for _, element := range list {
+		if got, _ := json.Marshal(element); string(got) == string(wanted) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+package stack
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsrds"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestSecurityPolicy_Rules(t *testing.T) {
+	tests := []struct {
+		name      string
+		build     func(stack awscdk.Stack)
+		wantRules []string
+	}{
+		{
+			name: "wildcard IAM resource",
+			build: func(stack awscdk.Stack) {
+				role := awsiam.NewRole(stack, jsii.String("Role"), &awsiam.RoleProps{
+					AssumedBy: awsiam.NewServicePrincipal(jsii.String("ecs-tasks.amazonaws.com"), nil),
+				})
+				role.AddToPolicy(awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+					Actions:   jsii.Strings("secretsmanager:GetSecretValue"),
+					Resources: jsii.Strings("*"),
+				}))
+			},
+			wantRules: []string{SecurityRuleWildcardIAMResource},
+		},
+		{
+			name: "actions without resource-level permissions may use a wildcard",
+			build: func(stack awscdk.Stack) {
+				role := awsiam.NewRole(stack, jsii.String("Role"), &awsiam.RoleProps{
+					AssumedBy: awsiam.NewServicePrincipal(jsii.String("ecs-tasks.amazonaws.com"), nil),
+				})
+				role.AddToPolicy(awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+					Actions:   jsii.Strings("ecr:GetAuthorizationToken"),
+					Resources: jsii.Strings("*"),
+				}))
+			},
+		},
+		{
+			name: "database in public subnets",
+			build: func(stack awscdk.Stack) {
+				vpc := awsec2.NewVpc(stack, jsii.String("Vpc"), &awsec2.VpcProps{
+					MaxAzs:              jsii.Number(2),
+					NatGateways:         jsii.Number(0),
+					SubnetConfiguration: &[]*awsec2.SubnetConfiguration{{Name: jsii.String("Public"), SubnetType: awsec2.SubnetType_PUBLIC}},
+				})
+				awsrds.NewSubnetGroup(stack, jsii.String("Subnets"), &awsrds.SubnetGroupProps{
+					Vpc:         vpc,
+					Description: jsii.String("database subnets"),
+					VpcSubnets:  &awsec2.SubnetSelection{SubnetType: awsec2.SubnetType_PUBLIC},
+				})
+			},
+			wantRules: []string{SecurityRulePublicDatabaseSubnet},
+		},
+		{
+			name: "bucket without encryption or access logs",
+			build: func(stack awscdk.Stack) {
+				awss3.NewCfnBucket(stack, jsii.String("Bucket"), &awss3.CfnBucketProps{})
+			},
+			wantRules: []string{SecurityRuleUnencryptedStorage, SecurityRuleMissingAccessLogs},
+		},
+		{
+			name: "literal secret in a function environment",
+			build: func(stack awscdk.Stack) {
+				awslambda.NewFunction(stack, jsii.String("Function"), &awslambda.FunctionProps{
+					Runtime: awslambda.Runtime_PYTHON_3_12(),
+					Handler: jsii.String("index.handler"),
+					Code:    awslambda.Code_FromInline(jsii.String("def handler(event, context): pass")),
+					Environment: &map[string]*string{
+						"API_KEY":        jsii.String("not-so-secret"),
+						"DB_SECRET_ARN":  jsii.String("arn:aws:secretsmanager:us-east-1:123456789012:secret:db"),
+						"GIT_TOKEN_PATH": jsii.String("/app/git-token"),
+					},
+				})
+			},
+			wantRules: []string{SecurityRulePlaintextSecretEnv, SecurityRulePlaintextSecretEnv},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			app := awscdk.NewApp(nil)
+			policy := AddSecurityPolicy(app, false)
+			tt.build(awscdk.NewStack(app, jsii.String("TestStack"), nil))
+
+			// Act
+			app.Synth(nil)
+
+			// Assert
+			violations := policy.Violations()
+			if len(violations) != len(tt.wantRules) {
+				t.Fatalf("got %d violations %v, want rules %v", len(violations), violations, tt.wantRules)
+			}
+			for i, violation := range violations {
+				if violation.Rule != tt.wantRules[i] {
+					t.Errorf("violation %d rule = %s, want %s", i, violation.Rule, tt.wantRules[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityPolicy_Enforcement(t *testing.T) {
+	build := func(enforce bool) (awscdk.Stack, *SecurityPolicy) {
+		app := awscdk.NewApp(nil)
+		policy := AddSecurityPolicy(app, enforce)
+		stack := awscdk.NewStack(app, jsii.String("TestStack"), nil)
+		awss3.NewBucket(stack, jsii.String("Bucket"), &awss3.BucketProps{
+			Encryption: awss3.BucketEncryption_S3_MANAGED,
+		})
+		return stack, policy
+	}
+
+	t.Run("enforced violations fail synthesis", func(_ *testing.T) {
+		// Arrange
+		stack, _ := build(true)
+
+		// Act
+		annotations := assertions.Annotations_FromStack(stack)
+
+		// Assert
+		annotations.HasError(jsii.String("/TestStack/Bucket/Resource"), assertions.Match_StringLikeRegexp(jsii.String(SecurityRuleMissingAccessLogs)))
+	})
+
+	t.Run("violations are warnings otherwise", func(_ *testing.T) {
+		// Arrange
+		stack, _ := build(false)
+
+		// Act
+		annotations := assertions.Annotations_FromStack(stack)
+
+		// Assert
+		annotations.HasNoError(jsii.String("*"), assertions.Match_AnyValue())
+		annotations.HasWarning(jsii.String("/TestStack/Bucket/Resource"), assertions.Match_StringLikeRegexp(jsii.String(SecurityRuleMissingAccessLogs)))
+	})
+}
+
+func TestSuppressSecurityRule(t *testing.T) {
+	t.Run("suppressed findings are reported with their justification", func(t *testing.T) {
+		// Arrange
+		app := awscdk.NewApp(nil)
+		policy := AddSecurityPolicy(app, true)
+		stack := awscdk.NewStack(app, jsii.String("TestStack"), nil)
+		bucket := awss3.NewBucket(stack, jsii.String("Bucket"), &awss3.BucketProps{
+			Encryption: awss3.BucketEncryption_S3_MANAGED,
+		})
+		SuppressSecurityRule(bucket, SecurityRuleMissingAccessLogs, "Short-lived scratch bucket")
+
+		// Act
+		annotations := assertions.Annotations_FromStack(stack)
+
+		// Assert
+		annotations.HasNoError(jsii.String("*"), assertions.Match_AnyValue())
+		findings := policy.Findings()
+		if len(findings) != 1 || !findings[0].Suppressed || findings[0].Justification != "Short-lived scratch bucket" {
+			t.Errorf("unexpected findings %+v", findings)
+		}
+	})
+
+	t.Run("a justification is required", func(_ *testing.T) {
+		// Arrange
+		app := awscdk.NewApp(nil)
+		stack := awscdk.NewStack(app, jsii.String("TestStack"), nil)
+		bucket := awss3.NewBucket(stack, jsii.String("Bucket"), nil)
+
+		// Act
+		SuppressSecurityRule(bucket, SecurityRuleMissingAccessLogs, " ")
+
+		// Assert
+		assertions.Annotations_FromStack(stack).HasError(jsii.String("/TestStack/Bucket"), assertions.Match_StringLikeRegexp(jsii.String("requires a justification")))
+	})
+}
+
+func TestSecurityPolicy_WriteReport(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	policy := AddSecurityPolicy(app, false)
+	stack := awscdk.NewStack(app, jsii.String("TestStack"), nil)
+	awss3.NewCfnBucket(stack, jsii.String("Bucket"), &awss3.CfnBucketProps{})
+	app.Synth(nil)
+	path := filepath.Join(t.TempDir(), SecurityReportFile)
+
+	// Act
+	err := policy.WriteReport(path)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("WriteReport() returned error: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	var report struct {
+		Rules    []map[string]string `json:"rules"`
+		Findings []SecurityFinding   `json:"findings"`
+	}
+	if err := json.Unmarshal(content, &report); err != nil {
+		t.Fatalf("report is not JSON: %v", err)
+	}
+	if len(report.Rules) != len(securityRules) {
+		t.Errorf("report lists %d rules, want %d", len(report.Rules), len(securityRules))
+	}
+	if len(report.Findings) != 2 || report.Findings[0].Path != "TestStack/Bucket" {
+		t.Errorf("unexpected findings %+v", report.Findings)
+	}
+}
+
+func TestAppStack_PassesEnforcedSecurityPolicy(t *testing.T) {
+	props := &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Region: jsii.String("us-east-1"),
+			},
+		},
+		Environment: ProdEnvironment(),
+	}
+
+	t.Run("single stack", func(t *testing.T) {
+		// Arrange
+		app := awscdk.NewApp(nil)
+		policy := AddSecurityPolicy(app, true)
+		NewAppStack(app, "TestStack", props)
+
+		// Act
+		app.Synth(nil)
+
+		// Assert
+		if violations := policy.Violations(); len(violations) != 0 {
+			t.Errorf("unexpected violations %+v", violations)
+		}
+	})
+
+	t.Run("split stacks", func(t *testing.T) {
+		// Arrange
+		app := awscdk.NewApp(nil)
+		policy := AddSecurityPolicy(app, true)
+		NewSplitAppStacks(app, "Split", props)
+
+		// Act
+		app.Synth(nil)
+
+		// Assert
+		if violations := policy.Violations(); len(violations) != 0 {
+			t.Errorf("unexpected violations %+v", violations)
+		}
+	})
+}
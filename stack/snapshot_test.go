@@ -0,0 +1,118 @@
+package stack
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// update regenerates the golden templates instead of comparing against them: `go test ./stack -run Snapshot -update`.
+var update = flag.Bool("update", false, "regenerate the golden templates in testdata")
+
+var (
+	// assetHashPattern matches asset hashes, which change whenever the Lambda source or its bundling image changes.
+	assetHashPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+	// tokenPattern matches unresolved CDK tokens, whose numbering depends on construction order.
+	tokenPattern = regexp.MustCompile(`\$\{Token\[[^\]]+\]\}`)
+)
+
+func TestAppStack_MatchesSnapshot(t *testing.T) {
+	for _, name := range []string{EnvironmentDev, EnvironmentStaging, EnvironmentProd} {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			environment, err := EnvironmentByName(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			app := awscdk.NewApp(&awscdk.AppProps{
+				AnalyticsReporting: jsii.Bool(false),
+			})
+			stack := NewAppStack(app, "TestStack", &AppStackProps{
+				StackProps: awscdk.StackProps{
+					Env: &awscdk.Environment{
+						Region: jsii.String("us-east-1"),
+					},
+				},
+				Environment: environment,
+			})
+
+			// Act
+			got := normalizeTemplate(t, assertions.Template_FromStack(stack.Stack, nil).ToJSON())
+
+			// Assert
+			goldenPath := filepath.Join("testdata", fmt.Sprintf("app_stack_%s.template.json", name))
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(goldenPath), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("failed to read %s, run `go test ./stack -run Snapshot -update` to create it: %v", goldenPath, err)
+			}
+			if diff := diffLines(string(want), string(got)); diff != "" {
+				t.Errorf("template differs from %s, run `go test ./stack -run Snapshot -update` if the change is intended:\n%s", goldenPath, diff)
+			}
+		})
+	}
+}
+
+// normalizeTemplate renders the template as indented JSON with asset hashes and tokens replaced by placeholders.
+func normalizeTemplate(t *testing.T, template *map[string]interface{}) []byte {
+	t.Helper()
+
+	content, err := json.MarshalIndent(template, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content = assetHashPattern.ReplaceAll(content, []byte("ASSET_HASH"))
+	content = tokenPattern.ReplaceAll(content, []byte("TOKEN"))
+	return append(content, '\n')
+}
+
+// diffLines lists the lines that differ between want and got, or returns an empty string when they are equal.
+func diffLines(want, got string) string {
+	if want == got {
+		return ""
+	}
+
+	const maxDifferences = 20
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	var builder strings.Builder
+	differences := 0
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var wantLine, gotLine string
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+		if wantLine == gotLine {
+			continue
+		}
+
+		differences++
+		if differences > maxDifferences {
+			builder.WriteString("...\n")
+			break
+		}
+		fmt.Fprintf(&builder, "line %d:\n- %s\n+ %s\n", i+1, wantLine, gotLine)
+	}
+	return builder.String()
+}
@@ -0,0 +1,163 @@
+package stack
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudfront"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscloudfrontorigins"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsroute53"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsroute53targets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// SpaHostingProps defines the properties for the SpaHosting construct.
+type SpaHostingProps struct {
+	// Environment supplies the removal policy. Defaults to DevEnvironment.
+	Environment *EnvironmentConfig
+
+	// Naming derives the bucket name. Defaults to DefaultNamePrefix.
+	Naming *Naming
+
+	// AccessLogs receives the bucket's server access logs under "s3/frontend/" and the distribution's standard
+	// logs under "cloudfront/". Leave nil to disable them.
+	AccessLogs awss3.IBucket
+
+	// Domains serves the distribution at FrontendDomainName when both are set.
+	Domains *DomainConfig
+}
+
+// SpaHosting serves a single page application from a private S3 bucket through CloudFront. Unknown paths
+// fall back to /index.html so client-side routing works.
+type SpaHosting struct {
+	constructs.Construct
+
+	// Bucket holds the built application; CI uploads to it.
+	Bucket awss3.IBucket
+	// BucketName is "<prefix>-frontend-<account>-<region>".
+	BucketName string
+	// Distribution serves the bucket over HTTPS.
+	Distribution awscloudfront.IDistribution
+	// DistributionID is the distribution ID, used for cache invalidations.
+	DistributionID string
+	// DistributionDomainName is the *.cloudfront.net domain of the distribution.
+	DistributionDomainName string
+	// URL is the URL the frontend is served from, on the custom frontend domain when one is configured.
+	URL string
+}
+
+// NewSpaHosting creates the S3 bucket and CloudFront distribution for React app hosting.
+func NewSpaHosting(scope constructs.Construct, id string, props *SpaHostingProps) *SpaHosting {
+	this := constructs.NewConstruct(scope, &id)
+	stack := awscdk.Stack_Of(this)
+	environment := environmentOrDefault(props.Environment)
+
+	// Create S3 bucket for frontend hosting
+	frontendBucketName := namingOrDefault(props.Naming).Name("frontend", *stack.Account(), *stack.Region())
+	frontendBucket := awss3.NewBucket(this, jsii.String("FrontendBucket"), &awss3.BucketProps{
+		BucketName:        jsii.String(frontendBucketName),
+		RemovalPolicy:     environment.statefulRemovalPolicy(),
+		AutoDeleteObjects: jsii.Bool(environment.autoDeleteOnRemoval()),
+		// Note: Not enabling website hosting since we use CloudFront with OAI
+		// Block public access at bucket level - CloudFront will access via OAI
+		BlockPublicAccess: awss3.BlockPublicAccess_BLOCK_ALL(),
+		Encryption:        awss3.BucketEncryption_S3_MANAGED,
+		EnforceSSL:        jsii.Bool(true),
+
+		ServerAccessLogsBucket: props.AccessLogs,
+		ServerAccessLogsPrefix: accessLogPrefix(props.AccessLogs, "s3/frontend/"),
+	})
+
+	// Create Origin Access Identity for CloudFront to access S3
+	originAccessIdentity := awscloudfront.NewOriginAccessIdentity(this, jsii.String("FrontendOAI"), &awscloudfront.OriginAccessIdentityProps{
+		Comment: jsii.String("OAI for Code Refactor Frontend"),
+	})
+
+	// Grant CloudFront OAI read access to the bucket
+	frontendBucket.GrantRead(originAccessIdentity.GrantPrincipal(), jsii.String("*"))
+
+	// Create CloudFront distribution
+	distributionProps := &awscloudfront.DistributionProps{
+		DefaultBehavior: &awscloudfront.BehaviorOptions{
+			// TODO: Replace with S3BucketOrigin when available in CDK version
+			//nolint:staticcheck // S3Origin is deprecated but S3BucketOrigin not available in this CDK version
+			Origin: awscloudfrontorigins.NewS3Origin(frontendBucket, &awscloudfrontorigins.S3OriginProps{
+				OriginAccessIdentity: originAccessIdentity,
+			}),
+			ViewerProtocolPolicy: awscloudfront.ViewerProtocolPolicy_REDIRECT_TO_HTTPS,
+			AllowedMethods:       awscloudfront.AllowedMethods_ALLOW_GET_HEAD(),
+			CachedMethods:        awscloudfront.CachedMethods_CACHE_GET_HEAD(),
+			Compress:             jsii.Bool(true),
+		},
+		// Configure for SPA (Single Page Application)
+		DefaultRootObject: jsii.String("index.html"),
+		ErrorResponses: &[]*awscloudfront.ErrorResponse{
+			{
+				HttpStatus:         jsii.Number(404),
+				ResponseHttpStatus: jsii.Number(200),
+				ResponsePagePath:   jsii.String("/index.html"),
+				Ttl:                awscdk.Duration_Minutes(jsii.Number(5)),
+			},
+			{
+				HttpStatus:         jsii.Number(403),
+				ResponseHttpStatus: jsii.Number(200),
+				ResponsePagePath:   jsii.String("/index.html"),
+				Ttl:                awscdk.Duration_Minutes(jsii.Number(5)),
+			},
+		},
+		Comment: jsii.String("Code Refactor Frontend Distribution"),
+		// Enable for better performance
+		EnableIpv6: jsii.Bool(true),
+		// Price class for cost optimization (use all edge locations for production)
+		PriceClass: awscloudfront.PriceClass_PRICE_CLASS_100,
+
+		EnableLogging: jsii.Bool(props.AccessLogs != nil),
+		LogBucket:     props.AccessLogs,
+		LogFilePrefix: accessLogPrefix(props.AccessLogs, "cloudfront/"),
+	}
+	customDomain := props.Domains != nil && props.Domains.FrontendDomainName != ""
+	var zone awsroute53.IHostedZone
+	if customDomain {
+		// CloudFront only accepts certificates from us-east-1
+		zone = props.Domains.hostedZone(this)
+		distributionProps.DomainNames = jsii.Strings(props.Domains.FrontendDomainName)
+		distributionProps.Certificate = newGlobalCertificate(this, "FrontendCertificate", props.Domains.FrontendDomainName, zone, environment)
+		distributionProps.MinimumProtocolVersion = awscloudfront.SecurityPolicyProtocol_TLS_V1_2_2021
+	}
+	distribution := awscloudfront.NewDistribution(this, jsii.String("FrontendDistribution"), distributionProps)
+	url := domainURL(*distribution.DistributionDomainName())
+	if customDomain {
+		addDistributionAliases(this, distribution, props.Domains.FrontendDomainName, zone, environment)
+		url = domainURL(props.Domains.FrontendDomainName)
+	}
+
+	// Apply removal policies for clean deletion
+	frontendBucket.ApplyRemovalPolicy(environment.statefulRemovalPolicy())
+	distribution.ApplyRemovalPolicy(environment.RemovalPolicy)
+	originAccessIdentity.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	return &SpaHosting{
+		Construct:              this,
+		Bucket:                 frontendBucket,
+		BucketName:             frontendBucketName,
+		Distribution:           distribution,
+		DistributionID:         *distribution.DistributionId(),
+		DistributionDomainName: *distribution.DistributionDomainName(),
+		URL:                    url,
+	}
+}
+
+// addDistributionAliases points the domain name at the distribution over IPv4 and IPv6.
+func addDistributionAliases(scope constructs.Construct, distribution awscloudfront.IDistribution, domainName string, zone awsroute53.IHostedZone, environment *EnvironmentConfig) {
+	target := awsroute53.RecordTarget_FromAlias(awsroute53targets.NewCloudFrontTarget(distribution))
+	awsroute53.NewARecord(scope, jsii.String("FrontendAlias"), &awsroute53.ARecordProps{
+		Zone:       zone,
+		RecordName: jsii.String(domainName),
+		Target:     target,
+	}).ApplyRemovalPolicy(environment.RemovalPolicy)
+	awsroute53.NewAaaaRecord(scope, jsii.String("FrontendAliasIpv6"), &awsroute53.AaaaRecordProps{
+		Zone:       zone,
+		RecordName: jsii.String(domainName),
+		Target:     target,
+	}).ApplyRemovalPolicy(environment.RemovalPolicy)
+}
@@ -0,0 +1,48 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestSpaHosting_StandsAloneWithDefaults(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("HostingStack"), &awscdk.StackProps{
+		Env: &awscdk.Environment{
+			Account: jsii.String("123456789012"),
+			Region:  jsii.String("eu-west-1"),
+		},
+	})
+
+	// Act
+	hosting := NewSpaHosting(stack, "Frontend", &SpaHostingProps{
+		Naming: NewNaming("docs-site"),
+	})
+	template := assertions.Template_FromStack(stack, nil)
+
+	// Assert
+	t.Run("creates a private bucket named from the prefix", func(t *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::S3::Bucket"), map[string]interface{}{
+			"BucketName": "docs-site-frontend-123456789012-eu-west-1",
+		})
+		if hosting.BucketName != "docs-site-frontend-123456789012-eu-west-1" {
+			t.Errorf("BucketName = %q", hosting.BucketName)
+		}
+	})
+
+	t.Run("falls back to index.html for client-side routes", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::CloudFront::Distribution"), map[string]interface{}{
+			"DistributionConfig": map[string]interface{}{
+				"DefaultRootObject": "index.html",
+				"CustomErrorResponses": []interface{}{
+					map[string]interface{}{"ErrorCode": 404, "ResponseCode": 200, "ResponsePagePath": "/index.html"},
+					map[string]interface{}{"ErrorCode": 403, "ResponseCode": 200, "ResponsePagePath": "/index.html"},
+				},
+			},
+		})
+	})
+}
@@ -0,0 +1,267 @@
+package stack
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/constructs-go/constructs/v10"
+)
+
+// NetworkStack holds the VPC so that it is deployed independently of everything that runs in it.
+type NetworkStack struct {
+	awscdk.Stack
+	Network *Network
+}
+
+// DataStackProps defines the properties for the data stack.
+type DataStackProps struct {
+	AppStackProps
+	Network *Network
+}
+
+// DataStack holds the stateful resources: the access log and knowledge base buckets, Aurora and the Cognito
+// user pool.
+type DataStack struct {
+	awscdk.Stack
+	AccessLogs    *AccessLogBucket
+	KnowledgeBase *KnowledgeBaseBucket
+	Database      *VectorDatabase
+	Users         *UserDirectory
+}
+
+// ComputeStackProps defines the properties for the compute stack.
+type ComputeStackProps struct {
+	AppStackProps
+	Network       *Network
+	AccessLogs    *AccessLogBucket
+	KnowledgeBase *KnowledgeBaseBucket
+	Database      *VectorDatabase
+	Users         *UserDirectory
+
+	// OutputsStackName names the stack whose outputs the running tasks may read. Defaults to the compute stack.
+	OutputsStackName string
+}
+
+// ComputeStack holds the Bedrock roles and the backend service with its load balancer.
+type ComputeStack struct {
+	awscdk.Stack
+	Bedrock *BedrockRoles
+	Service *RefactorService
+}
+
+// EdgeStackProps defines the properties for the edge stack.
+type EdgeStackProps struct {
+	AppStackProps
+	AccessLogs    *AccessLogBucket
+	KnowledgeBase *KnowledgeBaseBucket
+	Database      *VectorDatabase
+	Users         *UserDirectory
+	Bedrock       *BedrockRoles
+	Service       *RefactorService
+}
+
+// EdgeStack holds the public entry points (API Gateway and CloudFront frontend) together with the deployment
+// role, configuration stores and stack outputs.
+type EdgeStack struct {
+	awscdk.Stack
+	API           *RefactorAPI
+	Frontend      *SpaHosting
+	GitHubActions *GitHubActionsRole
+	Outputs       *OutputRegistry
+}
+
+// SplitAppStacks is the application deployed as four stacks wired through cross-stack references.
+type SplitAppStacks struct {
+	Network *NetworkStack
+	Data    *DataStack
+	Compute *ComputeStack
+	Edge    *EdgeStack
+}
+
+// NewNetworkStack creates the stack holding the VPC.
+func NewNetworkStack(scope constructs.Construct, id string, props *AppStackProps) *NetworkStack {
+	stack := awscdk.NewStack(scope, &id, &props.StackProps)
+	applyTagSchema(stack, props)
+	environment := environmentOrDefault(props.Environment)
+	naming := namingOrDefault(props.Naming)
+
+	return &NetworkStack{
+		Stack: stack,
+		Network: NewNetwork(stack, "Network", &NetworkProps{
+			NetworkConfig: networkingOrDefault(props.Networking),
+			Environment:   environment,
+			Naming:        naming,
+		}),
+	}
+}
+
+// NewDataStack creates the stack holding storage, database and authentication resources.
+func NewDataStack(scope constructs.Construct, id string, props *DataStackProps) *DataStack {
+	stack := awscdk.NewStack(scope, &id, &props.StackProps)
+	applyTagSchema(stack, &props.AppStackProps)
+	environment := environmentOrDefault(props.Environment)
+	naming := namingOrDefault(props.Naming)
+
+	accessLogs := NewAccessLogBucket(stack, "AccessLogs", &AccessLogBucketProps{
+		Environment: environment,
+	})
+
+	database := NewVectorDatabase(stack, "Database", &VectorDatabaseProps{
+		Vpc:                       props.Network.Vpc,
+		VpcSubnets:                props.Network.WorkloadSubnets,
+		ClusterSubnets:            props.Network.DatabaseSubnets,
+		Environment:               environment,
+		Naming:                    naming,
+		DatabaseName:              props.DatabaseName,
+		VectorStores:              vectorStoresOrDefault(props.VectorStores, props.VectorTableName),
+		Migrations:                migrationsOrDefault(props.Migrations),
+		Backup:                    props.DatabaseBackup,
+		RestoreSnapshotIdentifier: props.RestoreSnapshotIdentifier,
+		DualStack:                 props.Network.DualStack,
+	})
+	props.Network.DependOnEndpoints(database.MigrationLambda)
+
+	return &DataStack{
+		Stack:      stack,
+		AccessLogs: accessLogs,
+		KnowledgeBase: NewKnowledgeBaseBucket(stack, "KnowledgeBase", &KnowledgeBaseBucketProps{
+			Environment: environment,
+			Naming:      naming,
+			AccessLogs:  accessLogs.Bucket,
+		}),
+		Database: database,
+		Users: NewUserDirectory(stack, "Users", &UserDirectoryProps{
+			Environment: environment,
+			Naming:      naming,
+			Domains:     props.Domains,
+		}),
+	}
+}
+
+// NewComputeStack creates the stack holding the Bedrock roles and the backend service.
+func NewComputeStack(scope constructs.Construct, id string, props *ComputeStackProps) *ComputeStack {
+	stack := awscdk.NewStack(scope, &id, &props.StackProps)
+	applyTagSchema(stack, &props.AppStackProps)
+	environment := environmentOrDefault(props.Environment)
+	naming := namingOrDefault(props.Naming)
+
+	bedrock := NewBedrockRoles(stack, "Bedrock", &BedrockRolesProps{
+		KnowledgeBaseBucket: props.KnowledgeBase.Bucket,
+		Database:            props.Database,
+		FoundationModels:    props.FoundationModels,
+		Environment:         environment,
+	})
+
+	service := NewRefactorService(stack, "Service", &RefactorServiceProps{
+		Vpc:              props.Network.Vpc,
+		VpcSubnets:       props.Network.WorkloadSubnets,
+		Database:         props.Database,
+		KnowledgeBase:    props.KnowledgeBase,
+		Bedrock:          bedrock,
+		Users:            props.Users,
+		Environment:      environment,
+		Naming:           naming,
+		VectorTableName:  props.Database.VectorStores[0].TableName,
+		OutputsStackName: props.OutputsStackName,
+		AccessLogs:       props.AccessLogs.Bucket,
+		LoadBalancer:     loadBalancerOrDefault(props.LoadBalancer),
+		DualStack:        props.Network.DualStack,
+	})
+	props.Network.DependOnEndpoints(service.Service)
+
+	return &ComputeStack{
+		Stack:   stack,
+		Bedrock: bedrock,
+		Service: service,
+	}
+}
+
+// NewEdgeStack creates the stack holding the API, frontend, deployment role, configuration stores and outputs.
+func NewEdgeStack(scope constructs.Construct, id string, props *EdgeStackProps) *EdgeStack {
+	stack := awscdk.NewStack(scope, &id, &props.StackProps)
+	applyTagSchema(stack, &props.AppStackProps)
+	environment := environmentOrDefault(props.Environment)
+	naming := namingOrDefault(props.Naming)
+
+	api := NewRefactorAPI(stack, "API", &RefactorAPIProps{
+		LoadBalancer:       props.Service.LoadBalancer,
+		DomainName:         props.Service.DomainName,
+		VpcLinkTarget:      props.Service.VpcLinkTarget,
+		OriginVerification: props.Service.OriginVerification,
+		UserPool:           props.Users.UserPool,
+		Domains:            props.Domains,
+		Environment:        environment,
+		Naming:             naming,
+	})
+	frontend := NewSpaHosting(stack, "Frontend", &SpaHostingProps{
+		Environment: environment,
+		Naming:      naming,
+		AccessLogs:  props.AccessLogs.Bucket,
+		Domains:     props.Domains,
+	})
+	githubRole := NewGitHubActionsRole(stack, "GitHubActions", &GitHubActionsRoleProps{
+		Frontend:    frontend,
+		Environment: environment,
+		Naming:      naming,
+	})
+
+	components := &appComponents{
+		Environment:   environment,
+		Naming:        naming,
+		KnowledgeBase: props.KnowledgeBase,
+		Database:      props.Database,
+		Users:         props.Users,
+		Bedrock:       props.Bedrock,
+		Service:       props.Service,
+		API:           api,
+		Frontend:      frontend,
+		GitHubActions: githubRole,
+	}
+	outputs := publishOutputs(stack, naming, appOutputs(stack, components))
+	createSecretParameters(stack, components)
+
+	return &EdgeStack{
+		Stack:         stack,
+		API:           api,
+		Frontend:      frontend,
+		GitHubActions: githubRole,
+		Outputs:       outputs,
+	}
+}
+
+// NewSplitAppStacks creates the network, data, compute and edge stacks named "<id>-Network", "<id>-Data",
+// "<id>-Compute" and "<id>-Edge". A change to one layer only redeploys that layer and the ones after it.
+func NewSplitAppStacks(scope constructs.Construct, id string, props *AppStackProps) *SplitAppStacks {
+	network := NewNetworkStack(scope, id+"-Network", props)
+
+	data := NewDataStack(scope, id+"-Data", &DataStackProps{
+		AppStackProps: *props,
+		Network:       network.Network,
+	})
+
+	edgeID := id + "-Edge"
+	compute := NewComputeStack(scope, id+"-Compute", &ComputeStackProps{
+		AppStackProps:    *props,
+		Network:          network.Network,
+		AccessLogs:       data.AccessLogs,
+		KnowledgeBase:    data.KnowledgeBase,
+		Database:         data.Database,
+		Users:            data.Users,
+		OutputsStackName: edgeID,
+	})
+
+	edge := NewEdgeStack(scope, edgeID, &EdgeStackProps{
+		AppStackProps: *props,
+		AccessLogs:    data.AccessLogs,
+		KnowledgeBase: data.KnowledgeBase,
+		Database:      data.Database,
+		Users:         data.Users,
+		Bedrock:       compute.Bedrock,
+		Service:       compute.Service,
+	})
+
+	return &SplitAppStacks{
+		Network: network,
+		Data:    data,
+		Compute: compute,
+		Edge:    edge,
+	}
+}
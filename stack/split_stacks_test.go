@@ -0,0 +1,131 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestSplitAppStacks_PlacesResourcesPerLayer(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stacks := NewSplitAppStacks(app, "TestStack", &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Region: jsii.String("us-east-1"),
+			},
+		},
+	})
+
+	// Act
+	app.Synth(nil)
+	network := assertions.Template_FromStack(stacks.Network.Stack, nil)
+	data := assertions.Template_FromStack(stacks.Data.Stack, nil)
+	compute := assertions.Template_FromStack(stacks.Compute.Stack, nil)
+	edge := assertions.Template_FromStack(stacks.Edge.Stack, nil)
+
+	// Assert
+	t.Run("network stack holds the VPC", func(_ *testing.T) {
+		network.ResourceCountIs(jsii.String("AWS::EC2::VPC"), jsii.Number(1))
+		data.ResourceCountIs(jsii.String("AWS::EC2::VPC"), jsii.Number(0))
+	})
+
+	t.Run("data stack holds storage, database and user pool", func(_ *testing.T) {
+		data.ResourceCountIs(jsii.String("AWS::RDS::DBCluster"), jsii.Number(1))
+		// Knowledge base and access logs
+		data.ResourceCountIs(jsii.String("AWS::S3::Bucket"), jsii.Number(2))
+		data.ResourceCountIs(jsii.String("AWS::Cognito::UserPool"), jsii.Number(1))
+	})
+
+	t.Run("compute stack holds the service, load balancer and Bedrock roles", func(_ *testing.T) {
+		compute.ResourceCountIs(jsii.String("AWS::ECS::Cluster"), jsii.Number(1))
+		compute.ResourceCountIs(jsii.String("AWS::ECS::Service"), jsii.Number(1))
+		compute.ResourceCountIs(jsii.String("AWS::ElasticLoadBalancingV2::LoadBalancer"), jsii.Number(1))
+		compute.ResourceCountIs(jsii.String("AWS::ECS::TaskDefinition"), jsii.Number(1))
+		compute.ResourceCountIs(jsii.String("AWS::ECR::Repository"), jsii.Number(1))
+		compute.HasResourceProperties(jsii.String("AWS::IAM::Role"), map[string]interface{}{
+			"AssumeRolePolicyDocument": map[string]interface{}{
+				"Statement": []interface{}{
+					map[string]interface{}{
+						"Principal": map[string]interface{}{
+							"Service": "bedrock.amazonaws.com",
+						},
+					},
+				},
+			},
+		})
+	})
+
+	t.Run("edge stack holds the API, frontend and outputs", func(_ *testing.T) {
+		edge.ResourceCountIs(jsii.String("AWS::ApiGateway::RestApi"), jsii.Number(1))
+		edge.ResourceCountIs(jsii.String("AWS::CloudFront::Distribution"), jsii.Number(1))
+		edge.HasOutput(jsii.String("ECRRepositoryURI"), map[string]interface{}{
+			"Export": map[string]interface{}{
+				"Name": "CodeRefactor-ECR-Repository-URI",
+			},
+		})
+	})
+
+	t.Run("compute stack lets the ECS service reach the database", func(_ *testing.T) {
+		compute.HasResourceProperties(jsii.String("AWS::EC2::SecurityGroupIngress"), map[string]interface{}{
+			"FromPort":              5432,
+			"GroupId":               assertions.Match_ObjectLike(&map[string]interface{}{"Fn::ImportValue": assertions.Match_AnyValue()}),
+			"SourceSecurityGroupId": assertions.Match_AnyValue(),
+		})
+	})
+
+	t.Run("each layer depends on the one before it", func(t *testing.T) {
+		layers := []awscdk.Stack{stacks.Network.Stack, stacks.Data.Stack, stacks.Compute.Stack, stacks.Edge.Stack}
+		for i := 1; i < len(layers); i++ {
+			dependsOnPrevious := false
+			for _, dependency := range *layers[i].Dependencies() {
+				dependsOnPrevious = dependsOnPrevious || *dependency.StackName() == *layers[i-1].StackName()
+			}
+			if !dependsOnPrevious {
+				t.Errorf("%s does not depend on %s", *layers[i].StackName(), *layers[i-1].StackName())
+			}
+		}
+	})
+}
+
+func TestSplitAppStacks_DefaultsEnvironmentAndNaming(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	// Only the region is set, which load balancer access logging requires
+	props := &AppStackProps{
+		StackProps: awscdk.StackProps{Env: &awscdk.Environment{Region: jsii.String("us-east-1")}},
+	}
+
+	// Act
+	stacks := NewSplitAppStacks(app, "TestStack", props)
+	app.Synth(nil)
+
+	// Assert
+	t.Run("network stack holds the VPC", func(_ *testing.T) {
+		assertions.Template_FromStack(stacks.Network.Stack, nil).ResourceCountIs(jsii.String("AWS::EC2::VPC"), jsii.Number(1))
+	})
+
+	t.Run("data stack uses the default name prefix", func(_ *testing.T) {
+		data := assertions.Template_FromStack(stacks.Data.Stack, nil)
+		data.HasResourceProperties(jsii.String("AWS::RDS::DBCluster"), map[string]interface{}{
+			"DBClusterIdentifier": DefaultNamePrefix + "-cluster",
+		})
+		data.HasResourceProperties(jsii.String("AWS::Cognito::UserPool"), map[string]interface{}{
+			"UserPoolName": DefaultNamePrefix + "-user-pool",
+		})
+	})
+
+	t.Run("compute stack uses the default name prefix", func(_ *testing.T) {
+		assertions.Template_FromStack(stacks.Compute.Stack, nil).HasResourceProperties(jsii.String("AWS::ECR::Repository"), map[string]interface{}{
+			"RepositoryName": DefaultNamePrefix + "-ecr-repo",
+		})
+	})
+
+	t.Run("edge stack uses the default name prefix", func(_ *testing.T) {
+		assertions.Template_FromStack(stacks.Edge.Stack, nil).HasResourceProperties(jsii.String("AWS::ApiGateway::RestApi"), map[string]interface{}{
+			"Name": DefaultNamePrefix + "-api",
+		})
+	})
+}
@@ -0,0 +1,102 @@
+package stack
+
+import (
+	"sort"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+const (
+	// TagKeyEnvironment is the tag naming the environment profile, e.g. "prod".
+	TagKeyEnvironment = "environment"
+	// TagKeyOwner is the tag naming the team accountable for a resource.
+	TagKeyOwner = "owner"
+	// TagKeyCostCenter is the tag costs are allocated by.
+	TagKeyCostCenter = "cost-center"
+	// TagKeyDataClassification is the tag describing the sensitivity of the data a resource holds.
+	TagKeyDataClassification = "data-classification"
+
+	// DefaultTagOwner is the owner used when TagSchema.Owner is not set.
+	DefaultTagOwner = "code-refactoring"
+	// DefaultTagCostCenter is the cost center used when TagSchema.CostCenter is not set.
+	DefaultTagCostCenter = "code-refactoring"
+	// DefaultTagDataClassification is the classification used when TagSchema.DataClassification is not set.
+	// The stack stores customer source code and user accounts.
+	DefaultTagDataClassification = "confidential"
+
+	// tagPriority matches the priority of awscdk.Tags_Of so explicit tags on a resource can still override the schema.
+	tagPriority = 100
+)
+
+// TagSchema lists the tags every taggable resource carries.
+type TagSchema struct {
+	// Project is the "project" tag. Defaults to DefaultResourceTagValue.
+	Project string
+	// Environment is the "environment" tag. Defaults to the environment profile name.
+	Environment string
+	// Owner is the "owner" tag. Defaults to DefaultTagOwner.
+	Owner string
+	// CostCenter is the "cost-center" tag. Defaults to DefaultTagCostCenter.
+	CostCenter string
+	// DataClassification is the "data-classification" tag. Defaults to DefaultTagDataClassification.
+	DataClassification string
+}
+
+// Tags returns the schema as tag keys and values, filling in defaults for unset fields.
+func (s *TagSchema) Tags(environment *EnvironmentConfig) map[string]string {
+	schema := TagSchema{}
+	if s != nil {
+		schema = *s
+	}
+
+	return map[string]string{
+		DefaultResourceTagKey:    valueOrDefault(schema.Project, DefaultResourceTagValue),
+		TagKeyEnvironment:        valueOrDefault(schema.Environment, environmentOrDefault(environment).Name),
+		TagKeyOwner:              valueOrDefault(schema.Owner, DefaultTagOwner),
+		TagKeyCostCenter:         valueOrDefault(schema.CostCenter, DefaultTagCostCenter),
+		TagKeyDataClassification: valueOrDefault(schema.DataClassification, DefaultTagDataClassification),
+	}
+}
+
+// TaggingAspect sets a fixed set of tags on every taggable resource in the scope it is added to.
+type TaggingAspect struct {
+	tags map[string]string
+}
+
+// NewTaggingAspect creates an aspect applying the given tags.
+func NewTaggingAspect(tags map[string]string) *TaggingAspect {
+	return &TaggingAspect{tags: tags}
+}
+
+// Visit tags the node when it is a taggable resource.
+func (a *TaggingAspect) Visit(node constructs.IConstruct) {
+	manager := awscdk.TagManager_Of(node)
+	if manager == nil {
+		return
+	}
+
+	keys := make([]string, 0, len(a.tags))
+	for key := range a.tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		manager.SetTag(jsii.String(key), jsii.String(a.tags[key]), jsii.Number(tagPriority), jsii.Bool(true))
+	}
+}
+
+// applyTagSchema adds the tagging aspect for the props' tag schema to the stack.
+func applyTagSchema(stack awscdk.Stack, props *AppStackProps) {
+	awscdk.Aspects_Of(stack).Add(NewTaggingAspect(props.TagSchema.Tags(props.Environment)), nil)
+}
+
+// valueOrDefault returns value, or fallback when value is empty.
+func valueOrDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,154 @@
+package stack
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestTagSchema_Tags(t *testing.T) {
+	t.Run("defaults unset fields", func(t *testing.T) {
+		// Act
+		tags := (*TagSchema)(nil).Tags(StagingEnvironment())
+
+		// Assert
+		want := map[string]string{
+			DefaultResourceTagKey:    DefaultResourceTagValue,
+			TagKeyEnvironment:        EnvironmentStaging,
+			TagKeyOwner:              DefaultTagOwner,
+			TagKeyCostCenter:         DefaultTagCostCenter,
+			TagKeyDataClassification: DefaultTagDataClassification,
+		}
+		for key, value := range want {
+			if tags[key] != value {
+				t.Errorf("tag %q = %q, want %q", key, tags[key], value)
+			}
+		}
+	})
+
+	t.Run("keeps set fields", func(t *testing.T) {
+		// Arrange
+		schema := &TagSchema{Owner: "platform-team", DataClassification: "restricted"}
+
+		// Act
+		tags := schema.Tags(nil)
+
+		// Assert
+		if tags[TagKeyOwner] != "platform-team" || tags[TagKeyDataClassification] != "restricted" {
+			t.Errorf("unexpected tags %v", tags)
+		}
+		if tags[TagKeyEnvironment] != EnvironmentDev {
+			t.Errorf("environment tag = %q, want %q", tags[TagKeyEnvironment], EnvironmentDev)
+		}
+	})
+}
+
+func TestAppStack_TagsEveryTaggableResource(t *testing.T) {
+	// Arrange
+	props := &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Region: jsii.String("us-east-1"),
+			},
+		},
+		TagSchema: &TagSchema{Owner: "platform-team", CostCenter: "cc-1234"},
+	}
+	want := props.TagSchema.Tags(props.Environment)
+
+	app := awscdk.NewApp(nil)
+	single := NewAppStack(app, "TestStack", props)
+	split := NewSplitAppStacks(awscdk.NewApp(nil), "Split", props)
+
+	stacks := map[string]awscdk.Stack{
+		"single":  single.Stack,
+		"network": split.Network.Stack,
+		"data":    split.Data.Stack,
+		"compute": split.Compute.Stack,
+		"edge":    split.Edge.Stack,
+	}
+
+	for name, stack := range stacks {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			template := assertions.Template_FromStack(stack, nil)
+
+			// Assert
+			resources := templateProperties(template)
+			for logicalID, property := range taggableResources(stack) {
+				tags := tagValues(resources[logicalID][property])
+				for key, value := range want {
+					if tags[key] != value {
+						t.Errorf("%s: tag %q = %q, want %q", logicalID, key, tags[key], value)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("previously untagged resources carry the schema", func(_ *testing.T) {
+		template := assertions.Template_FromStack(single.Stack, nil)
+		template.HasResourceProperties(jsii.String("AWS::ApiGateway::Stage"), map[string]interface{}{
+			"Tags": assertions.Match_ArrayWith(&[]interface{}{
+				map[string]interface{}{"Key": TagKeyOwner, "Value": "platform-team"},
+			}),
+		})
+	})
+}
+
+// taggableResources maps the logical ID of every taggable CloudFormation resource in the stack to the
+// template property holding its tags.
+func taggableResources(stack awscdk.Stack) map[string]string {
+	resources := map[string]string{}
+	for _, node := range *stack.Node().FindAll(constructs.ConstructOrder_PREORDER) {
+		manager := awscdk.TagManager_Of(node)
+		resource, ok := node.(awscdk.CfnResource)
+		if manager == nil || !ok {
+			continue
+		}
+		logicalID, _ := stack.Resolve(resource.LogicalId()).(string)
+		property := *manager.TagPropertyName()
+		resources[logicalID] = strings.ToUpper(property[:1]) + property[1:]
+	}
+	return resources
+}
+
+// templateProperties returns the properties of each resource in the template, keyed by logical ID.
+func templateProperties(template assertions.Template) map[string]map[string]interface{} {
+	content, _ := json.Marshal(template.ToJSON())
+
+	var parsed struct {
+		Resources map[string]struct {
+			Properties map[string]interface{}
+		}
+	}
+	_ = json.Unmarshal(content, &parsed)
+
+	properties := map[string]map[string]interface{}{}
+	for logicalID, resource := range parsed.Resources {
+		properties[logicalID] = resource.Properties
+	}
+	return properties
+}
+
+// tagValues reads a tag property rendered either as a list of {Key, Value} pairs or as a map.
+func tagValues(property interface{}) map[string]string {
+	tags := map[string]string{}
+	switch value := property.(type) {
+	case []interface{}:
+		for _, entry := range value {
+			pair, _ := entry.(map[string]interface{})
+			key, _ := pair["Key"].(string)
+			tags[key], _ = pair["Value"].(string)
+		}
+	case map[string]interface{}:
+		for key, entry := range value {
+			tags[key], _ = entry.(string)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,157 @@
+package stack
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awscognito"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsroute53"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsroute53targets"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// UserDirectoryProps defines the properties for the UserDirectory construct.
+type UserDirectoryProps struct {
+	// Environment supplies the removal policy. Defaults to DevEnvironment.
+	Environment *EnvironmentConfig
+
+	// Naming derives the user pool, client and hosted UI domain names. Defaults to DefaultNamePrefix.
+	Naming *Naming
+
+	// Domains serves the hosted UI at AuthDomainName when both are set.
+	Domains *DomainConfig
+}
+
+// UserDirectory is the Cognito user pool the API authorizes requests against, with a public client and hosted UI.
+type UserDirectory struct {
+	constructs.Construct
+
+	// UserPool allows self sign-up with email or username and verifies email addresses.
+	UserPool awscognito.IUserPool
+	// UserPoolClient is a public client (no secret) for the web frontend.
+	UserPoolClient awscognito.IUserPoolClient
+	// UserPoolDomain serves the hosted UI at "<prefix>-<account>", or at the custom auth domain.
+	UserPoolDomain awscognito.IUserPoolDomain
+	// UserPoolID is the user pool ID.
+	UserPoolID string
+	// ClientID is the user pool client ID.
+	ClientID string
+	// DomainURL is the hosted UI domain prefix, or the custom auth domain name.
+	DomainURL string
+	// HostedUIURL is the base URL of the hosted UI.
+	HostedUIURL string
+}
+
+// NewUserDirectory creates the Cognito user pool, client and hosted UI domain.
+func NewUserDirectory(scope constructs.Construct, id string, props *UserDirectoryProps) *UserDirectory {
+	this := constructs.NewConstruct(scope, &id)
+	environment := environmentOrDefault(props.Environment)
+	naming := namingOrDefault(props.Naming)
+
+	// Create Cognito User Pool
+	userPool := awscognito.NewUserPool(this, jsii.String("CodeRefactorUserPool"), &awscognito.UserPoolProps{
+		UserPoolName:      jsii.String(naming.Name("user-pool")),
+		SelfSignUpEnabled: jsii.Bool(true),
+		SignInAliases: &awscognito.SignInAliases{
+			Email:    jsii.Bool(true),
+			Username: jsii.Bool(true),
+		},
+		AutoVerify: &awscognito.AutoVerifiedAttrs{
+			Email: jsii.Bool(true),
+		},
+		PasswordPolicy: &awscognito.PasswordPolicy{
+			MinLength:        jsii.Number(8),
+			RequireLowercase: jsii.Bool(true),
+			RequireUppercase: jsii.Bool(true),
+			RequireDigits:    jsii.Bool(true),
+			RequireSymbols:   jsii.Bool(true),
+		},
+		AccountRecovery:    awscognito.AccountRecovery_EMAIL_ONLY,
+		DeletionProtection: jsii.Bool(environment.DataProtection),
+	})
+
+	// Apply removal policy to User Pool for clean deletion
+	userPool.ApplyRemovalPolicy(environment.statefulRemovalPolicy())
+
+	// Create User Pool Client
+	userPoolClient := awscognito.NewUserPoolClient(this, jsii.String("CodeRefactorUserPoolClient"), &awscognito.UserPoolClientProps{
+		UserPool:           userPool,
+		UserPoolClientName: jsii.String(naming.Name("client")),
+		GenerateSecret:     jsii.Bool(false), // For public clients (web/mobile apps)
+		AuthFlows: &awscognito.AuthFlow{
+			UserPassword: jsii.Bool(true),
+			UserSrp:      jsii.Bool(true),
+		},
+		OAuth: &awscognito.OAuthSettings{
+			Flows: &awscognito.OAuthFlows{
+				AuthorizationCodeGrant: jsii.Bool(true),
+				ImplicitCodeGrant:      jsii.Bool(true),
+			},
+			Scopes: &[]awscognito.OAuthScope{
+				awscognito.OAuthScope_EMAIL(),
+				awscognito.OAuthScope_OPENID(),
+				awscognito.OAuthScope_PROFILE(),
+			},
+			CallbackUrls: oauthURLs(props.Domains, "/callback"),
+			LogoutUrls:   oauthURLs(props.Domains, "/logout"),
+		},
+		IdTokenValidity:      awscdk.Duration_Hours(jsii.Number(24)),
+		AccessTokenValidity:  awscdk.Duration_Hours(jsii.Number(24)),
+		RefreshTokenValidity: awscdk.Duration_Days(jsii.Number(30)),
+	})
+
+	// Apply removal policy to User Pool Client for clean deletion
+	userPoolClient.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	// Create Cognito User Pool Domain for Hosted UI
+	userPoolDomainProps := &awscognito.UserPoolDomainProps{
+		UserPool: userPool,
+		CognitoDomain: &awscognito.CognitoDomainOptions{
+			DomainPrefix: jsii.String(naming.Name(*awscdk.Stack_Of(this).Account())), // Must be globally unique
+		},
+	}
+	customDomain := props.Domains != nil && props.Domains.AuthDomainName != ""
+	var zone awsroute53.IHostedZone
+	if customDomain {
+		// The hosted UI is served through CloudFront, so its certificate must be in us-east-1
+		zone = props.Domains.hostedZone(this)
+		userPoolDomainProps.CognitoDomain = nil
+		userPoolDomainProps.CustomDomain = &awscognito.CustomDomainOptions{
+			DomainName:  jsii.String(props.Domains.AuthDomainName),
+			Certificate: newGlobalCertificate(this, "AuthDomainCertificate", props.Domains.AuthDomainName, zone, environment),
+		}
+	}
+	userPoolDomain := awscognito.NewUserPoolDomain(this, jsii.String("CodeRefactorUserPoolDomain"), userPoolDomainProps)
+
+	// Apply removal policy to User Pool Domain for clean deletion
+	userPoolDomain.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	if customDomain {
+		record := awsroute53.NewARecord(this, jsii.String("AuthDomainAlias"), &awsroute53.ARecordProps{
+			Zone:       zone,
+			RecordName: jsii.String(props.Domains.AuthDomainName),
+			Target:     awsroute53.RecordTarget_FromAlias(awsroute53targets.NewUserPoolDomainTarget(userPoolDomain)),
+		})
+		record.ApplyRemovalPolicy(environment.RemovalPolicy)
+	}
+
+	return &UserDirectory{
+		Construct:      this,
+		UserPool:       userPool,
+		UserPoolClient: userPoolClient,
+		UserPoolDomain: userPoolDomain,
+		UserPoolID:     *userPool.UserPoolId(),
+		ClientID:       *userPoolClient.UserPoolClientId(),
+		DomainURL:      *userPoolDomain.DomainName(),
+		HostedUIURL:    *userPoolDomain.BaseUrl(nil),
+	}
+}
+
+// oauthURLs returns the redirect URLs with the path for local development and for the frontend, served at its
+// custom domain when one is configured.
+func oauthURLs(domains *DomainConfig, path string) *[]*string {
+	frontend := "https://example.com" // Replace with your actual frontend URL
+	if domains != nil && domains.FrontendDomainName != "" {
+		frontend = "https://" + domains.FrontendDomainName
+	}
+	return jsii.Strings("https://localhost:3000"+path, frontend+path)
+}
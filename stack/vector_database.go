@@ -0,0 +1,291 @@
+package stack
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsbackup"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awslambda"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsrds"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3assets"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssecretsmanager"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// VectorDatabaseProps defines the properties for the VectorDatabase construct.
+type VectorDatabaseProps struct {
+	// Vpc is the VPC the cluster and migration Lambda are placed in. Required.
+	Vpc awsec2.IVpc
+
+	// VpcSubnets selects the subnets the migration Lambda runs in. Defaults to the public subnets.
+	VpcSubnets *awsec2.SubnetSelection
+
+	// ClusterSubnets selects the subnets the cluster is placed in. Defaults to the isolated subnets.
+	ClusterSubnets *awsec2.SubnetSelection
+
+	// Environment selects the Serverless v2 capacity and removal policy. Defaults to DevEnvironment.
+	Environment *EnvironmentConfig
+
+	// Naming derives the cluster identifier and secret name. Defaults to DefaultNamePrefix.
+	Naming *Naming
+
+	// DatabaseName is the default Aurora database name. Defaults to RDSPostgresDatabaseName.
+	DatabaseName string
+
+	// VectorStores are the vector tables the deployment creates and migrates. Defaults to a single
+	// RDSPostgresTableName store embedded with DefaultEmbeddingModel.
+	VectorStores []VectorStoreConfig
+
+	// SchemaVersion is the schema version the deployment applies; changing it runs the migration Lambda again.
+	// Defaults to SchemaVersion.
+	SchemaVersion string
+
+	// Migrations selects the target migration version and dry-run mode. Defaults to the latest version.
+	Migrations MigrationConfig
+
+	// DualStack lets the migration Lambda reach out over IPv6. The subnets must have IPv6 CIDRs.
+	DualStack bool
+
+	// Backup selects the automated backup retention, the backup and maintenance windows and an optional AWS Backup
+	// plan. Defaults to the environment's retention and the default windows.
+	Backup *DatabaseBackupConfig
+
+	// RestoreSnapshotIdentifier creates the cluster from this cluster snapshot, given by ARN or, in the same account,
+	// by name, instead of an empty volume. It only takes effect when the cluster is created; changing it on a
+	// deployed stack replaces the cluster.
+	RestoreSnapshotIdentifier string
+}
+
+// VectorDatabase is an Aurora PostgreSQL Serverless v2 cluster with the Data API enabled, its credentials
+// secret and the Lambda that creates the pgvector schema, which every deployment changing SchemaVersion runs.
+type VectorDatabase struct {
+	constructs.Construct
+
+	// Cluster is the Aurora PostgreSQL cluster listening on port 5432.
+	Cluster awsrds.IDatabaseCluster
+	// CredentialsSecret holds the "postgres" user's username and password.
+	CredentialsSecret awssecretsmanager.ISecret
+	// DatabaseName is the default database created in the cluster.
+	DatabaseName string
+	// MigrationLambda creates the database and migrates the vector tables when invoked.
+	MigrationLambda awslambda.IFunction
+	// MigrationLambdaRole is the execution role of MigrationLambda.
+	MigrationLambdaRole awsiam.IRole
+	// MigrationLambdaSG is the security group MigrationLambda connects to the cluster from.
+	MigrationLambdaSG awsec2.ISecurityGroup
+	// SchemaMigration invokes MigrationLambda on deployments that change the schema version.
+	SchemaMigration awscdk.CustomResource
+	// SchemaVersion is the schema version the last successful deployment applied.
+	SchemaVersion string
+	// ReaderEndpoint is the host name that balances connections over the readers, or reaches the writer when
+	// there are none.
+	ReaderEndpoint string
+	// VectorStores are the vector tables MigrationLambda migrates, with their defaults filled in. The first one is
+	// the primary store the backend uses.
+	VectorStores []VectorStoreConfig
+	// BackupVault holds the AWS Backup recovery points of the cluster, or is nil without a backup plan.
+	BackupVault awsbackup.IBackupVault
+}
+
+// NewVectorDatabase creates the RDS cluster, its credentials secret and the migration Lambda.
+func NewVectorDatabase(scope constructs.Construct, id string, props *VectorDatabaseProps) *VectorDatabase {
+	this := constructs.NewConstruct(scope, &id)
+	environment := environmentOrDefault(props.Environment)
+	naming := namingOrDefault(props.Naming)
+	databaseName := props.DatabaseName
+	if databaseName == "" {
+		databaseName = RDSPostgresDatabaseName
+	}
+
+	clusterSubnets := props.ClusterSubnets
+	if clusterSubnets == nil {
+		clusterSubnets = &awsec2.SubnetSelection{SubnetType: awsec2.SubnetType_PRIVATE_ISOLATED}
+	}
+
+	// Secrets Manager Secret
+	credentialsSecret := awssecretsmanager.NewSecret(this, jsii.String("CodeRefactorDbSecret"), &awssecretsmanager.SecretProps{
+		SecretName: jsii.String(naming.Name("db-secret")),
+		GenerateSecretString: &awssecretsmanager.SecretStringGenerator{
+			SecretStringTemplate: jsii.String("{\"username\": \"postgres\"}"),
+			GenerateStringKey:    jsii.String("password"),
+			ExcludeCharacters:    jsii.String("\"@/\\"),
+		},
+		RemovalPolicy: environment.statefulRemovalPolicy(),
+	})
+
+	writer, readers := databaseInstances(environment.DatabaseReaders, *props.Vpc.SelectSubnets(clusterSubnets).AvailabilityZones)
+
+	backup := databaseBackupOrDefault(props.Backup, environment)
+
+	// RDS Postgres Serverless v2
+	clusterProps := &awsrds.DatabaseClusterProps{
+		Engine: awsrds.DatabaseClusterEngine_AuroraPostgres(&awsrds.AuroraPostgresClusterEngineProps{
+			Version: awsrds.AuroraPostgresEngineVersion_VER_15_12(), // Updated to latest available version to exceed AWS recommendation
+		}),
+		Writer:              writer,
+		Readers:             &readers,
+		Vpc:                 props.Vpc,
+		VpcSubnets:          clusterSubnets,
+		DefaultDatabaseName: jsii.String(databaseName),
+		Port:                jsii.Number(5432),
+		Credentials:         awsrds.Credentials_FromSecret(credentialsSecret, jsii.String("postgres")),
+		RemovalPolicy:       environment.databaseRemovalPolicy(),
+		DeletionProtection:  jsii.Bool(environment.DataProtection),
+		ClusterIdentifier:   jsii.String(naming.Name("cluster")),
+		// Enable Data API v2 for Bedrock Knowledge Base integration
+		EnableDataApi: jsii.Bool(true),
+		// Configure Serverless v2 scaling
+		ServerlessV2MinCapacity: jsii.Number(environment.DatabaseMinCapacity),
+		ServerlessV2MaxCapacity: jsii.Number(environment.DatabaseMaxCapacity),
+		StorageEncrypted:        jsii.Bool(true),
+		// Automated backups for point-in-time recovery, and snapshots that keep the cluster's tags
+		Backup:                     backup.backupProps(),
+		PreferredMaintenanceWindow: jsii.String(backup.PreferredMaintenanceWindow),
+		CopyTagsToSnapshot:         jsii.Bool(true),
+	}
+	cluster := newDatabaseCluster(this, RDSPostgresDatabaseName, props.RestoreSnapshotIdentifier, clusterProps, credentialsSecret)
+	applyPromotionTiers(cluster, environment.DatabaseReaders)
+
+	vectorStores, errs := resolveVectorStores(vectorStoresOrDefault(props.VectorStores, ""))
+	for _, err := range errs {
+		awscdk.Annotations_Of(this).AddError(jsii.String(err.Error()))
+	}
+
+	database := &VectorDatabase{
+		Construct:         this,
+		Cluster:           cluster,
+		CredentialsSecret: credentialsSecret,
+		DatabaseName:      databaseName,
+		ReaderEndpoint:    *cluster.ClusterReadEndpoint().Hostname(),
+		VectorStores:      vectorStores,
+	}
+
+	// Create migration lambda and related resources
+	database.createMigrationLambda(props, environment)
+	database.addSchemaMigration(props)
+	database.addBackupPlan(backup, naming)
+
+	return database
+}
+
+// createMigrationLambda creates the database migration lambda and related resources
+func (d *VectorDatabase) createMigrationLambda(props *VectorDatabaseProps, environment *EnvironmentConfig) {
+	vpc := props.Vpc
+	subnets := workloadSubnetsOrDefault(props.VpcSubnets)
+
+	// Security Group for the Migration Lambda
+	migrationLambdaSG := awsec2.NewSecurityGroup(d.Construct, jsii.String("DbMigrationLambdaSG"), &awsec2.SecurityGroupProps{
+		Vpc:                  vpc,
+		Description:          jsii.String("Allow outbound connection to RDS Postgres for DB migrations"),
+		AllowAllOutbound:     jsii.Bool(true),
+		AllowAllIpv6Outbound: jsii.Bool(props.DualStack),
+	})
+
+	// Add inbound rule to RDS Security Group to allow connections from the Lambda SG
+	d.Cluster.Connections().AllowFrom(migrationLambdaSG, awsec2.Port_Tcp(jsii.Number(5432)), jsii.String("Allow DB migration lambda"))
+
+	// IAM Role for the Migration Lambda
+	migrationLambdaRole := awsiam.NewRole(d.Construct, jsii.String("DbMigrationLambdaRole"), &awsiam.RoleProps{
+		AssumedBy: awsiam.NewServicePrincipal(jsii.String("lambda.amazonaws.com"), nil),
+	})
+
+	// Apply removal policy to IAM role for clean deletion
+	migrationLambdaRole.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	// Grant permissions
+	setupMigrationLambdaPermissions(migrationLambdaRole, d.CredentialsSecret, d.Cluster)
+
+	lambdaPath := filepath.Join(getThisFileDir(), "../rds_schema_lambda")
+
+	// Lambda only assigns IPv6 addresses when asked to
+	var ipv6Allowed *bool
+	if props.DualStack {
+		ipv6Allowed = jsii.Bool(true)
+	}
+
+	// Lambda Function for Schema Migration
+	migrationLambda := awslambda.NewFunction(d.Construct, jsii.String("DbMigrationLambda"), &awslambda.FunctionProps{
+		Handler: jsii.String("handler.lambda_handler"),
+		Runtime: awslambda.Runtime_PYTHON_3_12(),
+		Code: awslambda.AssetCode_FromAsset(jsii.String(lambdaPath), &awss3assets.AssetOptions{
+			Bundling: &awscdk.BundlingOptions{
+				Image: awslambda.Runtime_PYTHON_3_12().BundlingImage(),
+				Command: jsii.Strings(
+					"bash", "-c",
+					"pip install -r requirements.txt -t /asset-output && cp -au . /asset-output",
+				),
+				User: jsii.String("root"),
+			},
+		}),
+		Vpc:        vpc,
+		VpcSubnets: subnets,
+		SecurityGroups: &[]awsec2.ISecurityGroup{
+			migrationLambdaSG,
+		},
+		Environment: &map[string]*string{
+			"DB_SECRET_ARN":        d.CredentialsSecret.SecretArn(),
+			"DB_NAME":              jsii.String(d.DatabaseName),
+			"DB_HOST":              d.Cluster.ClusterEndpoint().Hostname(),
+			"DB_PORT":              jsii.String("5432"),
+			"EMBEDDING_DIMENSIONS": jsii.String(strconv.Itoa(d.VectorStores[0].Dimensions())), // For tables that are not declared stores
+			"AUTO_MIGRATE_SCHEMA":  jsii.String("true"),                                       // Enable automatic schema migration
+		},
+		// Deployments wait for the whole migration run, including index builds and the advisory lock, and fail when
+		// it times out. Ten minutes leaves the provider framework, which gives up after 15, time to report.
+		Timeout:                 awscdk.Duration_Minutes(jsii.Number(10)),
+		Role:                    migrationLambdaRole,
+		AllowPublicSubnet:       vpc.SelectSubnets(subnets).HasPublic,
+		Ipv6AllowedForDualStack: ipv6Allowed,
+		// Reserved concurrency to limit ENI creation
+		ReservedConcurrentExecutions: jsii.Number(1),
+	})
+
+	// Apply removal policies to ensure clean deletion
+	migrationLambda.ApplyRemovalPolicy(environment.RemovalPolicy)
+	migrationLambdaSG.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	d.MigrationLambda = migrationLambda
+	d.MigrationLambdaRole = migrationLambdaRole
+	d.MigrationLambdaSG = migrationLambdaSG
+}
+
+// setupMigrationLambdaPermissions configures IAM permissions for the migration lambda
+func setupMigrationLambdaPermissions(role awsiam.Role, credentialsSecret awssecretsmanager.ISecret, cluster awsrds.IDatabaseCluster) {
+	// Grant the Lambda role permissions to write logs to CloudWatch
+	role.AddManagedPolicy(awsiam.ManagedPolicy_FromAwsManagedPolicyName(jsii.String("service-role/AWSLambdaBasicExecutionRole")))
+
+	// For VPC access
+	role.AddManagedPolicy(awsiam.ManagedPolicy_FromAwsManagedPolicyName(jsii.String("service-role/AWSLambdaVPCAccessExecutionRole")))
+
+	// Grant the Lambda role permissions to read the database secret
+	credentialsSecret.GrantRead(role, nil)
+
+	// Grant RDS Data API permissions
+	role.AddToPolicy(awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+		Actions: &[]*string{
+			jsii.String("rds-data:ExecuteStatement"),
+			jsii.String("rds-data:BatchExecuteStatement"),
+			jsii.String("rds-data:BeginTransaction"),
+			jsii.String("rds-data:CommitTransaction"),
+			jsii.String("rds-data:RollbackTransaction"),
+			jsii.String("rds-data:ExecuteSql"),
+			jsii.String("rds-data:DescribeTable"),
+		},
+		Resources: &[]*string{
+			cluster.ClusterArn(),
+		},
+	}))
+}
+
+func getThisFileDir() string {
+	_, filename, _, ok := runtime.Caller(0)
+	if !ok {
+		panic("unable to get current file path")
+	}
+	return filepath.Dir(filename)
+}
@@ -0,0 +1,503 @@
+package stack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestVectorDatabase_StandsAloneWithDefaults(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := awscdk.NewStack(app, jsii.String("DatabaseStack"), nil)
+	network := NewNetwork(stack, "Network", &NetworkProps{})
+
+	// Act
+	database := NewVectorDatabase(stack, "Database", &VectorDatabaseProps{
+		Vpc: network.Vpc,
+	})
+	template := assertions.Template_FromStack(stack, nil)
+
+	// Assert
+	t.Run("creates a Data API enabled cluster with the default names", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::RDS::DBCluster"), map[string]interface{}{
+			"DatabaseName":        RDSPostgresDatabaseName,
+			"DBClusterIdentifier": "code-refactor-cluster",
+			"EnableHttpEndpoint":  true,
+			"ServerlessV2ScalingConfiguration": map[string]interface{}{
+				"MinCapacity": DevEnvironment().DatabaseMinCapacity,
+				"MaxCapacity": DevEnvironment().DatabaseMaxCapacity,
+			},
+		})
+	})
+
+	t.Run("lets the migration Lambda reach the cluster", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::EC2::SecurityGroupIngress"), map[string]interface{}{
+			"FromPort":              5432,
+			"SourceSecurityGroupId": assertions.Match_AnyValue(),
+		})
+	})
+
+	t.Run("exposes its resources", func(t *testing.T) {
+		if database.DatabaseName != RDSPostgresDatabaseName {
+			t.Errorf("DatabaseName = %q, want %q", database.DatabaseName, RDSPostgresDatabaseName)
+		}
+		if database.Cluster == nil || database.CredentialsSecret == nil || database.MigrationLambda == nil {
+			t.Error("expected cluster, secret and migration Lambda to be set")
+		}
+	})
+}
+
+func TestVectorDatabase_SchemaMigration(t *testing.T) {
+	newTemplate := func(props *VectorDatabaseProps) (*VectorDatabase, assertions.Template) {
+		app := awscdk.NewApp(nil)
+		stack := awscdk.NewStack(app, jsii.String("DatabaseStack"), nil)
+		props.Vpc = NewNetwork(stack, "Network", &NetworkProps{}).Vpc
+		database := NewVectorDatabase(stack, "Database", props)
+		return database, assertions.Template_FromStack(stack, nil)
+	}
+
+	t.Run("migrates the default store to SchemaVersion once the writer is up", func(t *testing.T) {
+		// Arrange
+		database, template := newTemplate(&VectorDatabaseProps{})
+
+		// Act
+		migrations := template.FindResources(jsii.String("Custom::SchemaMigration"), map[string]interface{}{
+			"Properties": map[string]interface{}{
+				"SchemaVersion": SchemaVersion,
+				"Database":      RDSPostgresDatabaseName,
+				"VectorStores":  `[{"dimensions":"1536","index_method":"hnsw","index_options":"m = 16, ef_construction = 64","operator_class":"vector_cosine_ops","table":"vector_store"}]`,
+			},
+		})
+
+		// Assert
+		if len(*migrations) != 1 {
+			t.Fatalf("found %d schema migrations, want 1", len(*migrations))
+		}
+		for id, migration := range *migrations {
+			dependsOn, _ := (*migration)["DependsOn"].([]interface{})
+			writer := false
+			for _, dependency := range dependsOn {
+				if name, _ := dependency.(string); strings.Contains(name, "writer") {
+					writer = true
+				}
+			}
+			if !writer {
+				t.Errorf("%s does not depend on the writer instance: %v", id, dependsOn)
+			}
+		}
+		if database.SchemaMigration == nil || database.SchemaVersion == "" {
+			t.Error("expected the schema migration and its version to be set")
+		}
+	})
+
+	t.Run("runs again when the version changes", func(_ *testing.T) {
+		// Arrange
+		_, template := newTemplate(&VectorDatabaseProps{SchemaVersion: "v7"})
+
+		// Act & Assert
+		template.HasResourceProperties(jsii.String("Custom::SchemaMigration"), map[string]interface{}{
+			"SchemaVersion": "v7",
+		})
+	})
+
+	t.Run("migrates to the latest version by default", func(_ *testing.T) {
+		// Arrange
+		_, template := newTemplate(&VectorDatabaseProps{})
+
+		// Act & Assert
+		template.HasResourceProperties(jsii.String("Custom::SchemaMigration"), map[string]interface{}{
+			"TargetVersion": "latest",
+			"DryRun":        "false",
+		})
+	})
+
+	t.Run("passes the target version and dry-run mode only as properties", func(_ *testing.T) {
+		// Arrange
+		_, template := newTemplate(&VectorDatabaseProps{Migrations: MigrationConfig{TargetVersion: 1, DryRun: true}})
+
+		// Act & Assert
+		// A rollback runs the Lambda with the previous properties before restoring its environment
+		template.HasResourceProperties(jsii.String("AWS::Lambda::Function"), map[string]interface{}{
+			"Environment": map[string]interface{}{
+				"Variables": assertions.Match_ObjectLike(&map[string]interface{}{
+					"MIGRATION_TARGET_VERSION": assertions.Match_Absent(),
+					"MIGRATION_DRY_RUN":        assertions.Match_Absent(),
+				}),
+			},
+		})
+		template.HasResourceProperties(jsii.String("Custom::SchemaMigration"), map[string]interface{}{
+			"TargetVersion": "1",
+			"DryRun":        "true",
+		})
+	})
+}
+
+func TestVectorDatabase_VectorStores(t *testing.T) {
+	newStack := func(stores []VectorStoreConfig) (awscdk.Stack, *VectorDatabase) {
+		app := awscdk.NewApp(nil)
+		stack := awscdk.NewStack(app, jsii.String("DatabaseStack"), nil)
+		database := NewVectorDatabase(stack, "Database", &VectorDatabaseProps{
+			Vpc:          NewNetwork(stack, "Network", &NetworkProps{}).Vpc,
+			VectorStores: stores,
+		})
+		return stack, database
+	}
+
+	t.Run("migrates every store with the dimensions of its model", func(t *testing.T) {
+		// Arrange
+		stack, database := newStack([]VectorStoreConfig{
+			{TableName: "documents"},
+			{TableName: "images", EmbeddingModel: "amazon.titan-embed-image-v1", IndexType: VectorIndexIVFFlat, DistanceMetric: DistanceMetricEuclidean},
+			{TableName: "normalized", EmbeddingModel: "amazon.titan-embed-text-v2:0", DistanceMetric: DistanceMetricInnerProduct},
+		})
+
+		// Act
+		template := assertions.Template_FromStack(stack, nil)
+
+		// Assert
+		vectorStores := `[` +
+			`{"dimensions":"1536","index_method":"hnsw","index_options":"m = 16, ef_construction = 64","operator_class":"vector_cosine_ops","table":"documents"},` +
+			`{"dimensions":"1024","index_method":"ivfflat","index_options":"lists = 100","operator_class":"vector_l2_ops","table":"images"},` +
+			`{"dimensions":"1024","index_method":"hnsw","index_options":"m = 16, ef_construction = 64","operator_class":"vector_ip_ops","table":"normalized"}` +
+			`]`
+		template.HasResourceProperties(jsii.String("AWS::Lambda::Function"), map[string]interface{}{
+			"Environment": map[string]interface{}{
+				"Variables": assertions.Match_ObjectLike(&map[string]interface{}{
+					"VECTOR_STORES":        assertions.Match_Absent(),
+					"EMBEDDING_DIMENSIONS": "1536",
+				}),
+			},
+		})
+		template.HasResourceProperties(jsii.String("Custom::SchemaMigration"), map[string]interface{}{
+			"VectorStores": vectorStores,
+		})
+		if got := database.VectorStores[0]; got.EmbeddingModel != DefaultEmbeddingModel || got.IndexType != VectorIndexHNSW || got.DistanceMetric != DistanceMetricCosine {
+			t.Errorf("VectorStores[0] = %+v, want the defaults filled in", got)
+		}
+	})
+
+	t.Run("rejects invalid stores", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			stores []VectorStoreConfig
+			error  string
+		}{
+			"unknown model":        {[]VectorStoreConfig{{TableName: "docs", EmbeddingModel: "amazon.titan-text-lite-v1"}}, "unknown embedding model"},
+			"unknown index type":   {[]VectorStoreConfig{{TableName: "docs", IndexType: "diskann"}}, "unknown vector index type"},
+			"unknown metric":       {[]VectorStoreConfig{{TableName: "docs", DistanceMetric: "hamming"}}, "unknown distance metric"},
+			"invalid table name":   {[]VectorStoreConfig{{TableName: "Docs-2024"}}, "lower case SQL identifier"},
+			"duplicate table name": {[]VectorStoreConfig{{TableName: "docs"}, {TableName: "docs"}}, "declared more than once"},
+		} {
+			t.Run(name, func(_ *testing.T) {
+				// Arrange
+				stack, _ := newStack(tc.stores)
+
+				// Act
+				annotations := assertions.Annotations_FromStack(stack)
+
+				// Assert
+				annotations.HasError(jsii.String("*"), assertions.Match_StringLikeRegexp(jsii.String(tc.error)))
+			})
+		}
+	})
+}
+
+func TestAppStack_VectorStores(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := NewAppStack(app, "TestStack", &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Account: jsii.String("123456789012"),
+				Region:  jsii.String("us-east-1"),
+			},
+		},
+		VectorTableName: "ignored",
+		VectorStores: []VectorStoreConfig{
+			{TableName: "documents", EmbeddingModel: "cohere.embed-english-v3"},
+			{TableName: "images", EmbeddingModel: "amazon.titan-embed-image-v1"},
+		},
+	})
+
+	// Act
+	template := assertions.Template_FromStack(stack.Stack, nil)
+
+	// Assert
+	t.Run("points the backend at the first store", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::ECS::TaskDefinition"), map[string]interface{}{
+			"ContainerDefinitions": assertions.Match_ArrayWith(&[]interface{}{
+				assertions.Match_ObjectLike(&map[string]interface{}{
+					"Environment": assertions.Match_ArrayWith(&[]interface{}{
+						map[string]interface{}{"Name": "AI_BEDROCK_RDS_POSTGRES_TABLE_NAME", "Value": "documents"},
+					}),
+				}),
+			}),
+		})
+	})
+
+	t.Run("lets the knowledge base embed with the stores' models", func(_ *testing.T) {
+		template.HasResourceProperties(jsii.String("AWS::IAM::Role"), map[string]interface{}{
+			"Policies": assertions.Match_ArrayWith(&[]interface{}{
+				assertions.Match_ObjectLike(&map[string]interface{}{
+					"PolicyName": "BedrockKbPolicy",
+					"PolicyDocument": assertions.Match_ObjectLike(&map[string]interface{}{
+						"Statement": assertions.Match_ArrayWith(&[]interface{}{
+							assertions.Match_ObjectLike(&map[string]interface{}{
+								"Action": "bedrock:InvokeModel",
+								"Resource": []interface{}{
+									"arn:aws:bedrock:us-east-1::foundation-model/cohere.embed-english-v3",
+									"arn:aws:bedrock:us-east-1::foundation-model/amazon.titan-embed-image-v1",
+								},
+							}),
+						}),
+					}),
+				}),
+			}),
+		})
+	})
+}
+
+func TestAppStack_PublishesSchemaVersion(t *testing.T) {
+	// Arrange
+	app := awscdk.NewApp(nil)
+	stack := NewAppStack(app, "TestStack", &AppStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Account: jsii.String("123456789012"),
+				Region:  jsii.String("us-east-1"),
+			},
+		},
+	})
+
+	// Act
+	template := assertions.Template_FromStack(stack.Stack, nil)
+
+	// Assert
+	template.HasOutput(jsii.String(OutputRDSPostgresSchemaVersion), map[string]interface{}{
+		"Value": map[string]interface{}{
+			"Fn::GetAtt": assertions.Match_ArrayWith(&[]interface{}{schemaVersionAttribute}),
+		},
+	})
+}
+
+func TestAppStack_DatabaseReaders(t *testing.T) {
+	newTemplate := func(environment *EnvironmentConfig) (*AppStack, assertions.Template) {
+		app := awscdk.NewApp(nil)
+		stack := NewAppStack(app, "TestStack", &AppStackProps{
+			StackProps: awscdk.StackProps{
+				Env: &awscdk.Environment{
+					Account: jsii.String("123456789012"),
+					Region:  jsii.String("us-east-1"),
+				},
+			},
+			Environment: environment,
+		})
+		return stack, assertions.Template_FromStack(stack.Stack, nil)
+	}
+
+	t.Run("prod places the writer and reader in different availability zones", func(_ *testing.T) {
+		// Arrange
+		_, template := newTemplate(ProdEnvironment())
+
+		// Act & Assert
+		template.ResourceCountIs(jsii.String("AWS::RDS::DBInstance"), jsii.Number(2))
+		template.HasResourceProperties(jsii.String("AWS::RDS::DBInstance"), map[string]interface{}{
+			"DBInstanceClass":  "db.serverless",
+			"PromotionTier":    0,
+			"AvailabilityZone": "dummy1a",
+		})
+		template.HasResourceProperties(jsii.String("AWS::RDS::DBInstance"), map[string]interface{}{
+			"DBInstanceClass":  "db.serverless",
+			"PromotionTier":    1,
+			"AvailabilityZone": "dummy1b",
+		})
+		template.HasResourceProperties(jsii.String("AWS::RDS::DBSubnetGroup"), map[string]interface{}{
+			"SubnetIds": assertions.Match_ArrayWith(&[]interface{}{
+				map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("DatabaseSubnet1"))},
+				map[string]interface{}{"Ref": assertions.Match_StringLikeRegexp(jsii.String("DatabaseSubnet2"))},
+			}),
+		})
+		for _, zone := range []string{"dummy1a", "dummy1b"} {
+			template.HasResourceProperties(jsii.String("AWS::EC2::Subnet"), map[string]interface{}{
+				"AvailabilityZone": zone,
+				"Tags":             assertions.Match_ArrayWith(&[]interface{}{map[string]interface{}{"Key": "aws-cdk:subnet-name", "Value": databaseSubnetGroup}}),
+			})
+		}
+	})
+
+	t.Run("spreads provisioned and serverless readers round-robin", func(_ *testing.T) {
+		// Arrange
+		environment := DevEnvironment()
+		environment.DatabaseReaders = []DatabaseReaderConfig{
+			{InstanceType: "r6g.large"},
+			{PromotionTier: 5},
+		}
+		_, template := newTemplate(environment)
+
+		// Act & Assert
+		template.ResourceCountIs(jsii.String("AWS::RDS::DBInstance"), jsii.Number(3))
+		template.HasResourceProperties(jsii.String("AWS::RDS::DBInstance"), map[string]interface{}{
+			"DBInstanceClass":  "db.r6g.large",
+			"PromotionTier":    0,
+			"AvailabilityZone": "dummy1b",
+		})
+		template.HasResourceProperties(jsii.String("AWS::RDS::DBInstance"), map[string]interface{}{
+			"DBInstanceClass":  "db.serverless",
+			"PromotionTier":    5,
+			"AvailabilityZone": "dummy1a",
+		})
+	})
+
+	t.Run("exposes the reader endpoint to the backend", func(t *testing.T) {
+		// Arrange
+		stack, template := newTemplate(ProdEnvironment())
+		readerEndpoint := map[string]interface{}{
+			"Fn::GetAtt": assertions.Match_ArrayWith(&[]interface{}{"ReadEndpoint.Address"}),
+		}
+
+		// Act & Assert
+		template.HasResourceProperties(jsii.String("AWS::ECS::TaskDefinition"), map[string]interface{}{
+			"ContainerDefinitions": assertions.Match_ArrayWith(&[]interface{}{
+				assertions.Match_ObjectLike(&map[string]interface{}{
+					"Environment": assertions.Match_ArrayWith(&[]interface{}{
+						map[string]interface{}{"Name": "AI_BEDROCK_RDS_POSTGRES_READER_ENDPOINT", "Value": readerEndpoint},
+					}),
+				}),
+			}),
+		})
+		template.HasResourceProperties(jsii.String("AWS::SSM::Parameter"), map[string]interface{}{
+			"Name":  "/code-refactor/backend/rds-reader-endpoint",
+			"Value": readerEndpoint,
+		})
+		template.HasOutput(jsii.String(OutputRDSPostgresReaderEndpoint), map[string]interface{}{
+			"Value": readerEndpoint,
+		})
+		if stack.RDSPostgresReaderEndpoint == "" {
+			t.Error("RDSPostgresReaderEndpoint should not be empty")
+		}
+	})
+}
+
+func TestAppStack_DatabaseBackup(t *testing.T) {
+	newStack := func(environment *EnvironmentConfig, backup *DatabaseBackupConfig, snapshot string) *AppStack {
+		app := awscdk.NewApp(nil)
+		return NewAppStack(app, "TestStack", &AppStackProps{
+			StackProps: awscdk.StackProps{
+				Env: &awscdk.Environment{
+					Account: jsii.String("123456789012"),
+					Region:  jsii.String("us-east-1"),
+				},
+			},
+			Environment:               environment,
+			DatabaseBackup:            backup,
+			RestoreSnapshotIdentifier: snapshot,
+		})
+	}
+
+	t.Run("keeps the environment's retention and tags snapshots", func(_ *testing.T) {
+		// Arrange
+		stack := newStack(ProdEnvironment(), nil, "")
+
+		// Act
+		template := assertions.Template_FromStack(stack.Stack, nil)
+
+		// Assert
+		template.HasResourceProperties(jsii.String("AWS::RDS::DBCluster"), map[string]interface{}{
+			"BackupRetentionPeriod":      35,
+			"PreferredBackupWindow":      "03:00-04:00",
+			"PreferredMaintenanceWindow": "sun:04:30-sun:05:30",
+			"CopyTagsToSnapshot":         true,
+		})
+		template.ResourceCountIs(jsii.String("AWS::Backup::BackupPlan"), jsii.Number(0))
+	})
+
+	t.Run("applies custom retention and windows", func(_ *testing.T) {
+		// Arrange
+		stack := newStack(DevEnvironment(), &DatabaseBackupConfig{
+			RetentionDays:              14,
+			PreferredBackupWindow:      "22:00-22:30",
+			PreferredMaintenanceWindow: "sat:23:00-sat:23:30",
+		}, "")
+
+		// Act
+		template := assertions.Template_FromStack(stack.Stack, nil)
+
+		// Assert
+		template.HasResourceProperties(jsii.String("AWS::RDS::DBCluster"), map[string]interface{}{
+			"BackupRetentionPeriod":      14,
+			"PreferredBackupWindow":      "22:00-22:30",
+			"PreferredMaintenanceWindow": "sat:23:00-sat:23:30",
+		})
+	})
+
+	t.Run("backs the cluster up into a locked vault and copies it", func(_ *testing.T) {
+		// Arrange
+		copyVault := "arn:aws:backup:us-west-2:123456789012:backup-vault:code-refactor-dr"
+		stack := newStack(DevEnvironment(), &DatabaseBackupConfig{Plan: &BackupPlanConfig{
+			RetentionDays:    30,
+			ContinuousBackup: true,
+			VaultLock:        &VaultLockConfig{MinRetentionDays: 7, MaxRetentionDays: 90, ChangeableForDays: 3},
+			CopyToVaultArn:   copyVault,
+		}}, "")
+
+		// Act
+		template := assertions.Template_FromStack(stack.Stack, nil)
+
+		// Assert
+		template.HasResource(jsii.String("AWS::Backup::BackupVault"), map[string]interface{}{
+			"DeletionPolicy": "Retain",
+			"Properties": map[string]interface{}{
+				"BackupVaultName": "code-refactor-db-backups",
+				"LockConfiguration": map[string]interface{}{
+					"MinRetentionDays":  7,
+					"MaxRetentionDays":  90,
+					"ChangeableForDays": 3,
+				},
+			},
+		})
+		template.HasResourceProperties(jsii.String("AWS::Backup::BackupPlan"), map[string]interface{}{
+			"BackupPlan": map[string]interface{}{
+				"BackupPlanName": "code-refactor-db-backup-plan",
+				"BackupPlanRule": []interface{}{
+					assertions.Match_ObjectLike(&map[string]interface{}{
+						"RuleName":               "Daily",
+						"ScheduleExpression":     "cron(0 6 * * ? *)",
+						"EnableContinuousBackup": true,
+						"Lifecycle":              map[string]interface{}{"DeleteAfterDays": 30},
+						"CopyActions": []interface{}{map[string]interface{}{
+							"DestinationBackupVaultArn": copyVault,
+							"Lifecycle":                 map[string]interface{}{"DeleteAfterDays": 30},
+						}},
+					}),
+				},
+			},
+		})
+		template.HasResourceProperties(jsii.String("AWS::Backup::BackupSelection"), map[string]interface{}{
+			"BackupSelection": assertions.Match_ObjectLike(&map[string]interface{}{
+				"Resources": assertions.Match_ArrayWith(&[]interface{}{
+					assertions.Match_ObjectLike(&map[string]interface{}{"Fn::Join": assertions.Match_AnyValue()}),
+				}),
+			}),
+		})
+	})
+
+	t.Run("restores the cluster from a snapshot", func(_ *testing.T) {
+		// Arrange
+		stack := newStack(DevEnvironment(), nil, "code-refactor-rehearsal")
+
+		// Act
+		template := assertions.Template_FromStack(stack.Stack, nil)
+
+		// Assert
+		template.HasResourceProperties(jsii.String("AWS::RDS::DBCluster"), map[string]interface{}{
+			"SnapshotIdentifier":    "code-refactor-rehearsal",
+			"DatabaseName":          assertions.Match_Absent(),
+			"MasterUsername":        assertions.Match_Absent(),
+			"BackupRetentionPeriod": 1,
+			"MasterUserPassword":    assertions.Match_AnyValue(),
+		})
+	})
+}
@@ -0,0 +1,203 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// VectorIndexType is the approximate nearest neighbour index pgvector builds over a vector store's embeddings.
+type VectorIndexType string
+
+const (
+	// VectorIndexHNSW builds a layered graph, which answers queries faster and with better recall than IVFFlat at
+	// the cost of slower builds and more memory. It is the default.
+	VectorIndexHNSW VectorIndexType = "hnsw"
+
+	// VectorIndexIVFFlat partitions the embeddings into lists, which builds faster and smaller than HNSW. Its
+	// recall depends on the rows present when the index was built, so it suits tables loaded before migrating.
+	VectorIndexIVFFlat VectorIndexType = "ivfflat"
+)
+
+// DistanceMetric is the similarity measure a vector store's index is built for. Queries only use the index when
+// they order by the matching pgvector operator.
+type DistanceMetric string
+
+const (
+	// DistanceMetricCosine orders by cosine distance, the <=> operator. It is the default.
+	DistanceMetricCosine DistanceMetric = "cosine"
+
+	// DistanceMetricEuclidean orders by L2 distance, the <-> operator.
+	DistanceMetricEuclidean DistanceMetric = "euclidean"
+
+	// DistanceMetricInnerProduct orders by negative inner product, the <#> operator. It suits normalized
+	// embeddings.
+	DistanceMetricInnerProduct DistanceMetric = "inner-product"
+)
+
+// DefaultEmbeddingModel is the Bedrock model a vector store is embedded with unless it names another.
+const DefaultEmbeddingModel = "amazon.titan-embed-text-v1"
+
+// EmbeddingModelDimensions maps the Bedrock embedding models a vector store may use to the length of the vectors
+// they return, which fixes the store's column type.
+var EmbeddingModelDimensions = map[string]int{
+	"amazon.titan-embed-text-v1":   1536,
+	"amazon.titan-embed-text-v2:0": 1024,
+	"amazon.titan-embed-image-v1":  1024,
+	"cohere.embed-english-v3":      1024,
+	"cohere.embed-multilingual-v3": 1024,
+}
+
+// vectorTableNamePattern matches the lower case SQL identifiers the migrations can use unquoted in index names.
+var vectorTableNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// VectorStoreConfig declares a pgvector table the migration Lambda creates and migrates. The embedding model and
+// index of an existing store are fixed once migrated; use a new table, or a new migration, to change them.
+type VectorStoreConfig struct {
+	// TableName is the table holding the embeddings, in lower case. Required.
+	TableName string
+
+	// EmbeddingModel is the Bedrock model the store is embedded with, which sets its dimensions. Must be a key of
+	// EmbeddingModelDimensions. Defaults to DefaultEmbeddingModel.
+	EmbeddingModel string
+
+	// IndexType selects the approximate nearest neighbour index. Defaults to VectorIndexHNSW.
+	IndexType VectorIndexType
+
+	// DistanceMetric selects the similarity measure the index serves. Defaults to DistanceMetricCosine.
+	DistanceMetric DistanceMetric
+}
+
+// ParseVectorIndexType returns the index type with the given name. An empty name selects VectorIndexHNSW.
+func ParseVectorIndexType(name string) (VectorIndexType, error) {
+	switch indexType := VectorIndexType(name); indexType {
+	case "":
+		return VectorIndexHNSW, nil
+	case VectorIndexHNSW, VectorIndexIVFFlat:
+		return indexType, nil
+	default:
+		return VectorIndexHNSW, fmt.Errorf("unknown vector index type %q", name)
+	}
+}
+
+// ParseDistanceMetric returns the distance metric with the given name. An empty name selects DistanceMetricCosine.
+func ParseDistanceMetric(name string) (DistanceMetric, error) {
+	switch metric := DistanceMetric(name); metric {
+	case "":
+		return DistanceMetricCosine, nil
+	case DistanceMetricCosine, DistanceMetricEuclidean, DistanceMetricInnerProduct:
+		return metric, nil
+	default:
+		return DistanceMetricCosine, fmt.Errorf("unknown distance metric %q", name)
+	}
+}
+
+// Dimensions is the length of the store's embeddings, or zero for an unknown model.
+func (v VectorStoreConfig) Dimensions() int {
+	return EmbeddingModelDimensions[v.EmbeddingModel]
+}
+
+// resolve fills in the defaults and returns the first problem with the store.
+func (v VectorStoreConfig) resolve() (VectorStoreConfig, error) {
+	if v.EmbeddingModel == "" {
+		v.EmbeddingModel = DefaultEmbeddingModel
+	}
+	indexType, err := ParseVectorIndexType(string(v.IndexType))
+	if err != nil {
+		return v, fmt.Errorf("vector store %q: %w", v.TableName, err)
+	}
+	metric, err := ParseDistanceMetric(string(v.DistanceMetric))
+	if err != nil {
+		return v, fmt.Errorf("vector store %q: %w", v.TableName, err)
+	}
+	v.IndexType, v.DistanceMetric = indexType, metric
+
+	switch {
+	case !vectorTableNamePattern.MatchString(v.TableName):
+		return v, fmt.Errorf("vector store %q: table name must be a lower case SQL identifier", v.TableName)
+	case v.Dimensions() == 0:
+		return v, fmt.Errorf("vector store %q: unknown embedding model %q", v.TableName, v.EmbeddingModel)
+	}
+	return v, nil
+}
+
+// operatorClass is the pgvector operator class that indexes the store's distance metric.
+func (v VectorStoreConfig) operatorClass() string {
+	switch v.DistanceMetric {
+	case DistanceMetricEuclidean:
+		return "vector_l2_ops"
+	case DistanceMetricInnerProduct:
+		return "vector_ip_ops"
+	default:
+		return "vector_cosine_ops"
+	}
+}
+
+// indexOptions are the index storage parameters, which are pgvector's defaults for each index type.
+func (v VectorStoreConfig) indexOptions() string {
+	if v.IndexType == VectorIndexIVFFlat {
+		return "lists = 100"
+	}
+	return "m = 16, ef_construction = 64"
+}
+
+// vectorStoresOrDefault returns the declared vector stores, or a single store for the table name when none are
+// declared.
+func vectorStoresOrDefault(stores []VectorStoreConfig, tableName string) []VectorStoreConfig {
+	if len(stores) > 0 {
+		return stores
+	}
+	if tableName == "" {
+		tableName = RDSPostgresTableName
+	}
+	return []VectorStoreConfig{{TableName: tableName}}
+}
+
+// resolveVectorStores fills in the defaults of every store and returns the problems with them.
+func resolveVectorStores(stores []VectorStoreConfig) ([]VectorStoreConfig, []error) {
+	resolved := make([]VectorStoreConfig, len(stores))
+	seen := make(map[string]bool, len(stores))
+	var errs []error
+	for i, store := range stores {
+		var err error
+		if resolved[i], err = store.resolve(); err != nil {
+			errs = append(errs, err)
+		}
+		if seen[store.TableName] {
+			errs = append(errs, fmt.Errorf("vector store %q is declared more than once", store.TableName))
+		}
+		seen[store.TableName] = true
+	}
+	return resolved, errs
+}
+
+// vectorStoreParameters is the VectorStores property of the schema migration: the migration template parameters
+// of every store, keyed by the placeholders the migrations use.
+func vectorStoreParameters(stores []VectorStoreConfig) string {
+	parameters := make([]map[string]string, len(stores))
+	for i, store := range stores {
+		parameters[i] = map[string]string{
+			"table":          store.TableName,
+			"dimensions":     fmt.Sprint(store.Dimensions()),
+			"index_method":   string(store.IndexType),
+			"operator_class": store.operatorClass(),
+			"index_options":  store.indexOptions(),
+		}
+	}
+	// Maps of strings always marshal
+	encoded, _ := json.Marshal(parameters)
+	return string(encoded)
+}
+
+// embeddingModels lists the distinct embedding models of the stores.
+func embeddingModels(stores []VectorStoreConfig) []string {
+	var models []string
+	seen := make(map[string]bool, len(stores))
+	for _, store := range stores {
+		if !seen[store.EmbeddingModel] {
+			seen[store.EmbeddingModel] = true
+			models = append(models, store.EmbeddingModel)
+		}
+	}
+	return models
+}
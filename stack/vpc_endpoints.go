@@ -0,0 +1,188 @@
+package stack
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// policyEndpoint is a gateway or interface endpoint whose endpoint policy can be extended.
+type policyEndpoint interface {
+	awsec2.IVpcEndpoint
+	AddToPolicy(statement awsiam.PolicyStatement)
+}
+
+// vpcEndpoint is an endpoint together with the statements of its endpoint policy.
+type vpcEndpoint struct {
+	id         string
+	service    awsec2.IInterfaceVpcEndpointService
+	statements []*awsiam.PolicyStatementProps
+}
+
+// createEndpoints adds the S3 and DynamoDB gateway endpoints and the interface endpoints for the services the
+// workloads call. Each endpoint policy only admits principals of this account acting on the stack's resources.
+func (n *Network) createEndpoints(naming *Naming, environment *EnvironmentConfig) {
+	stack := awscdk.Stack_Of(n.Construct)
+	partition, region, account := *stack.Partition(), *stack.Region(), *stack.Account()
+	vpc := n.Vpc
+
+	// Gateway endpoints only route the workload subnets; the database subnets stay without any route out
+	s3 := vpc.AddGatewayEndpoint(jsii.String("S3Endpoint"), &awsec2.GatewayVpcEndpointOptions{
+		Service: awsec2.GatewayVpcEndpointAwsService_S3(),
+		Subnets: &[]*awsec2.SubnetSelection{n.WorkloadSubnets},
+	})
+	addEndpointStatements(s3, account, &awsiam.PolicyStatementProps{
+		Actions: jsii.Strings("s3:*"),
+		Resources: jsii.Strings(
+			fmt.Sprintf("arn:%s:s3:::%s", partition, naming.Name("*")),
+			fmt.Sprintf("arn:%s:s3:::%s/*", partition, naming.Name("*")),
+		),
+	}, &awsiam.PolicyStatementProps{
+		// ECR serves image layers from this AWS owned bucket
+		Actions:   jsii.Strings("s3:GetObject"),
+		Resources: jsii.Strings(fmt.Sprintf("arn:%s:s3:::prod-%s-starport-layer-bucket/*", partition, region)),
+	})
+
+	dynamoDB := vpc.AddGatewayEndpoint(jsii.String("DynamoDBEndpoint"), &awsec2.GatewayVpcEndpointOptions{
+		Service: awsec2.GatewayVpcEndpointAwsService_DYNAMODB(),
+		Subnets: &[]*awsec2.SubnetSelection{n.WorkloadSubnets},
+	})
+	addEndpointStatements(dynamoDB, account, &awsiam.PolicyStatementProps{
+		Actions:   jsii.Strings("dynamodb:*"),
+		Resources: jsii.Strings(fmt.Sprintf("arn:%s:dynamodb:%s:%s:table/%s", partition, region, account, naming.Name("*"))),
+	})
+
+	ecrStatements := []*awsiam.PolicyStatementProps{
+		{
+			Actions:   jsii.Strings("ecr:GetAuthorizationToken"),
+			Resources: jsii.Strings("*"),
+		},
+		{
+			Actions:   jsii.Strings("ecr:*"),
+			Resources: jsii.Strings(fmt.Sprintf("arn:%s:ecr:%s:%s:repository/%s", partition, region, account, naming.Name("*"))),
+		},
+	}
+	interfaceEndpoints := []vpcEndpoint{
+		n.secretsManagerEndpoint(naming),
+		{id: "EcrApiEndpoint", service: awsec2.InterfaceVpcEndpointAwsService_ECR(), statements: ecrStatements},
+		{id: "EcrDockerEndpoint", service: awsec2.InterfaceVpcEndpointAwsService_ECR_DOCKER(), statements: ecrStatements},
+		{
+			// Lambda log group names are generated, so the policy admits every log group of the account
+			id:      "LogsEndpoint",
+			service: awsec2.InterfaceVpcEndpointAwsService_CLOUDWATCH_LOGS(),
+			statements: []*awsiam.PolicyStatementProps{{
+				Actions:   jsii.Strings("logs:CreateLogStream", "logs:PutLogEvents", "logs:DescribeLogStreams"),
+				Resources: jsii.Strings(fmt.Sprintf("arn:%s:logs:%s:%s:log-group:*", partition, region, account)),
+			}},
+		},
+		{
+			id:      "SsmEndpoint",
+			service: awsec2.InterfaceVpcEndpointAwsService_SSM(),
+			statements: []*awsiam.PolicyStatementProps{{
+				Actions:   jsii.Strings("ssm:GetParameter", "ssm:GetParameters", "ssm:GetParametersByPath"),
+				Resources: jsii.Strings(fmt.Sprintf("arn:%s:ssm:%s:%s:parameter%s/*", partition, region, account, naming.ParameterPath())),
+			}},
+		},
+		{
+			id:      "RdsDataEndpoint",
+			service: awsec2.InterfaceVpcEndpointAwsService_RDS_DATA(),
+			statements: []*awsiam.PolicyStatementProps{{
+				Actions:   jsii.Strings("rds-data:*"),
+				Resources: jsii.Strings(fmt.Sprintf("arn:%s:rds:%s:%s:cluster:%s", partition, region, account, naming.Name("cluster"))),
+			}},
+		},
+		{
+			id:      "BedrockRuntimeEndpoint",
+			service: awsec2.InterfaceVpcEndpointAwsService_BEDROCK_RUNTIME(),
+			statements: []*awsiam.PolicyStatementProps{{
+				Actions: jsii.Strings("bedrock:InvokeModel", "bedrock:InvokeModelWithResponseStream"),
+				Resources: jsii.Strings(
+					fmt.Sprintf("arn:%s:bedrock:%s::foundation-model/*", partition, region),
+					fmt.Sprintf("arn:%s:bedrock:%s:%s:*", partition, region, account),
+				),
+			}},
+		},
+		{
+			id:      "BedrockAgentRuntimeEndpoint",
+			service: awsec2.InterfaceVpcEndpointAwsService_BEDROCK_AGENT_RUNTIME(),
+			statements: []*awsiam.PolicyStatementProps{{
+				Actions:   jsii.Strings("bedrock:InvokeAgent", "bedrock:Retrieve", "bedrock:RetrieveAndGenerate"),
+				Resources: jsii.Strings(fmt.Sprintf("arn:%s:bedrock:%s:%s:*", partition, region, account)),
+			}},
+		},
+	}
+
+	n.Endpoints = []awsec2.IVpcEndpoint{s3, dynamoDB}
+	n.addInterfaceEndpoints(environment, interfaceEndpoints...)
+}
+
+// createSecretsManagerEndpoint adds only the Secrets Manager interface endpoint. Lambda functions never get a
+// public IP, so in a VPC without NAT or endpoints it is the only way the migration Lambda reads the database
+// credentials.
+func (n *Network) createSecretsManagerEndpoint(naming *Naming, environment *EnvironmentConfig) {
+	n.addInterfaceEndpoints(environment, n.secretsManagerEndpoint(naming))
+}
+
+// secretsManagerEndpoint is the Secrets Manager interface endpoint, admitting the stack's secrets.
+func (n *Network) secretsManagerEndpoint(naming *Naming) vpcEndpoint {
+	stack := awscdk.Stack_Of(n.Construct)
+	partition, region, account := *stack.Partition(), *stack.Region(), *stack.Account()
+	return vpcEndpoint{
+		id:      "SecretsManagerEndpoint",
+		service: awsec2.InterfaceVpcEndpointAwsService_SECRETS_MANAGER(),
+		statements: []*awsiam.PolicyStatementProps{{
+			Actions: jsii.Strings("secretsmanager:GetSecretValue", "secretsmanager:DescribeSecret"),
+			Resources: jsii.Strings(
+				fmt.Sprintf("arn:%s:secretsmanager:%s:%s:secret:%s", partition, region, account, naming.Name("*")),
+				fmt.Sprintf("arn:%s:secretsmanager:%s:%s:secret:%s/*", partition, region, account, naming.ParameterPath()),
+			),
+		}},
+	}
+}
+
+// addInterfaceEndpoints adds the interface endpoints in the workload subnets behind a shared security group.
+func (n *Network) addInterfaceEndpoints(environment *EnvironmentConfig, definitions ...vpcEndpoint) {
+	account := *awscdk.Stack_Of(n.Construct).Account()
+
+	// Interface endpoints share one security group admitting HTTPS from the VPC CIDR. Workload security groups
+	// never reference it, so deleting a workload does not wait on the endpoints and vice versa.
+	securityGroup := awsec2.NewSecurityGroup(n.Construct, jsii.String("EndpointSG"), &awsec2.SecurityGroupProps{
+		Vpc:              n.Vpc,
+		Description:      jsii.String("Allow HTTPS from the VPC to the interface endpoints"),
+		AllowAllOutbound: jsii.Bool(false),
+	})
+	securityGroup.AddIngressRule(awsec2.Peer_Ipv4(n.Vpc.VpcCidrBlock()), awsec2.Port_Tcp(jsii.Number(443)), jsii.String("Allow HTTPS from the VPC"), nil)
+	if n.DualStack {
+		securityGroup.AddIngressRule(awsec2.Peer_Ipv6(n.ipv6CidrBlock), awsec2.Port_Tcp(jsii.Number(443)), jsii.String("Allow HTTPS from the VPC over IPv6"), nil)
+	}
+	securityGroup.ApplyRemovalPolicy(environment.RemovalPolicy)
+
+	for _, definition := range definitions {
+		endpoint := n.Vpc.AddInterfaceEndpoint(jsii.String(definition.id), &awsec2.InterfaceVpcEndpointOptions{
+			Service:        definition.service,
+			Subnets:        n.WorkloadSubnets,
+			SecurityGroups: &[]awsec2.ISecurityGroup{securityGroup},
+			Open:           jsii.Bool(false),
+		})
+		endpoint.ApplyRemovalPolicy(environment.RemovalPolicy)
+		addEndpointStatements(endpoint, account, definition.statements...)
+		n.Endpoints = append(n.Endpoints, endpoint)
+	}
+}
+
+// addEndpointStatements replaces the endpoint's allow-all default policy with statements restricted to
+// principals of the given account.
+func addEndpointStatements(endpoint policyEndpoint, account string, statements ...*awsiam.PolicyStatementProps) {
+	for _, props := range statements {
+		statement := *props
+		statement.Effect = awsiam.Effect_ALLOW
+		statement.Principals = &[]awsiam.IPrincipal{awsiam.NewAnyPrincipal()}
+		statement.Conditions = &map[string]interface{}{
+			"StringEquals": map[string]interface{}{"aws:PrincipalAccount": account},
+		}
+		endpoint.AddToPolicy(awsiam.NewPolicyStatement(&statement))
+	}
+}